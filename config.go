@@ -1,14 +1,17 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"reflect"
 
-	"github.com/krostar/config/internal/trivialerr"
+	"github.com/krostar/config/trivialerr"
 )
 
 // Config stores the source configuration applied through options.
 type Config struct {
-	sources []Source
+	sources         []Source
+	aggregateErrors bool
 }
 
 // New creates a new config instance configured through options.
@@ -34,36 +37,153 @@ func Load(cfg interface{}, opts ...Option) error {
 	return c.Load(cfg)
 }
 
+// LoadContext creates a new instance (see New), and call the LoadContext method of it (see Config.LoadContext).
+func LoadContext(ctx context.Context, cfg interface{}, opts ...Option) error {
+	c, err := New(opts...)
+	if err != nil {
+		return err
+	}
+
+	return c.LoadContext(ctx, cfg)
+}
+
+// LoadWithReport creates a new instance (see New), and call the LoadWithReport method of it (see Config.LoadWithReport).
+func LoadWithReport(cfg interface{}, opts ...Option) (LoadReport, error) {
+	c, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.LoadWithReport(cfg)
+}
+
 // Load tries to apply defaults to the provided interface and
-// call all sources to load the configuration.
+// call all sources to load the configuration. Types that implement
+// BeforeLoad() error are called once defaults are applied, and types
+// that implement AfterLoad() error once all sources are loaded.
 func (c *Config) Load(cfg interface{}, opts ...Option) error {
+	return c.load(context.Background(), cfg, nil, opts...)
+}
+
+// LoadContext loads the configuration like Load does. Loading stops as soon as the
+// context is done, and the context is given to sources that are able to use it.
+func (c *Config) LoadContext(ctx context.Context, cfg interface{}, opts ...Option) error {
+	return c.load(ctx, cfg, nil, opts...)
+}
+
+// LoadWithReport loads the configuration like Load does, and returns
+// a report telling, for each config tree path, where the value came from.
+func (c *Config) LoadWithReport(cfg interface{}, opts ...Option) (LoadReport, error) {
+	var report = make(LoadReport)
+
+	if err := c.load(context.Background(), cfg, report, opts...); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (c *Config) load(ctx context.Context, cfg interface{}, report LoadReport, opts ...Option) error {
 	for _, opt := range opts {
 		if err := opt(c); err != nil {
 			return fmt.Errorf("unable to apply option: %w", err)
 		}
 	}
 
+	// knowing what has been set is needed to check required fields
+	var checkRequired = hasRequiredFields(reflect.TypeOf(cfg))
+	if checkRequired && report == nil {
+		report = make(LoadReport)
+	}
+
+	var snapshot configTreeSnapshot
+	if report != nil {
+		snapshot = takeConfigTreeSnapshot(cfg)
+		for treePath := range snapshot {
+			report[treePath] = LoadReportUnset
+		}
+	}
+
 	if err := SetDefault(cfg); err != nil {
 		return fmt.Errorf("unable to set defaults: %w", err)
 	}
 
+	// values set by before load hooks are reported as defaults
+	if err := callBeforeLoad(cfg); err != nil {
+		return fmt.Errorf("before load hook failed: %w", err)
+	}
+
+	if report != nil {
+		newSnapshot := takeConfigTreeSnapshot(cfg)
+		report.reportChanges(LoadReportDefault, snapshot, newSnapshot)
+		snapshot = newSnapshot
+	}
+
+	// errs is only used when errors are aggregated
+	var errs LoadErrors
+
 	for _, source := range c.sources {
-		if err := c.loadSource(source, cfg); err != nil {
+		if err := ctx.Err(); err != nil {
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
+
+		if err := c.loadSource(ctx, source, cfg, report, &errs); err != nil {
+			if !c.aggregateErrors {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			errs = append(errs, newLoadError(source.Name(), "", err))
+		}
+
+		if report != nil {
+			newSnapshot := takeConfigTreeSnapshot(cfg)
+			report.reportChanges(source.Name(), snapshot, newSnapshot)
+			snapshot = newSnapshot
+		}
+	}
+
+	if checkRequired {
+		if err := checkRequiredFields(cfg, report, c.sources); err != nil {
+			if !c.aggregateErrors {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			errs = append(errs, newLoadError("", "", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to load configuration: %w", errs)
+	}
+
+	if err := callAfterLoad(cfg); err != nil {
+		return fmt.Errorf("after load hook failed: %w", err)
 	}
 
 	return nil
 }
 
-func (c *Config) loadSource(source Source, cfg interface{}) error {
+func (c *Config) loadSource(
+	ctx context.Context, source Source, cfg interface{}, report LoadReport, errs *LoadErrors,
+) error {
+	if s, ok := source.(SourceRefresher); ok {
+		if err := s.Refresh(ctx); err != nil {
+			if trivialerr.IsTrivial(err) {
+				return nil
+			}
+			return fmt.Errorf("unable to refresh %s: %w", source.Name(), err)
+		}
+	}
+
 	var err error
 
-	if s, ok := source.(SourceUnmarshal); ok {
+	// sources that does not handle context are adapted to do so
+	switch s := source.(type) {
+	case SourceUnmarshalContext:
+		err = s.UnmarshalContext(ctx, cfg)
+	case SourceUnmarshal:
 		err = s.Unmarshal(cfg)
-	} else if s, ok := source.(SourceSetValueFromConfigTreePath); ok {
-		err = setValuesForEachAttributes(s, cfg)
-	} else {
+	case SourceSetValueFromConfigTreePathContext, SourceSetValueFromConfigTreePath:
+		err = c.setValues(ctx, s, cfg, report, errs)
+	default:
 		err = fmt.Errorf("%s does not fulfill any load interface", source.Name())
 	}
 
@@ -73,3 +193,23 @@ func (c *Config) loadSource(source Source, cfg interface{}) error {
 
 	return err
 }
+
+func (c *Config) setValues(
+	ctx context.Context, source Source, cfg interface{}, report LoadReport, errs *LoadErrors,
+) error {
+	setter := newConfigTreeSetter(ctx, source)
+
+	if c.aggregateErrors {
+		setter.onError = func(treePath string, err error) {
+			*errs = append(*errs, newLoadError(source.Name(), treePath, err))
+		}
+	}
+
+	if report != nil {
+		// tree path sources tell exactly what they set, even
+		// if the value is the same as the one already there
+		setter.onSet = func(treePath string) { report[treePath] = source.Name() }
+	}
+
+	return setter.setValues(cfg)
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -105,3 +107,36 @@ func TestConfig_Load_opts_applied(t *testing.T) {
 
 	assert.Equal(t, []Source{s1, s2}, loader.sources)
 }
+
+func Test_LoadContext(t *testing.T) {
+	type icfg struct {
+		Hello string
+	}
+
+	var (
+		cfg icfg
+		ctx = context.WithValue(context.Background(), stubContextKey{}, "value")
+		s1  = new(stubSourceThatUnmarshalWithContext)
+		s2  = stubSourceThatUseReflectionWithContext{stubSourceThatUseReflection{"hello": "world"}}
+	)
+
+	require.NoError(t, LoadContext(ctx, &cfg, WithRawSources(s1, s2)))
+	assert.Equal(t, "value", s1.ctxValue)
+	assert.Equal(t, "world", cfg.Hello)
+
+	// sources are not called once the context is done
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	s1.ctxValue = nil
+
+	err := LoadContext(ctx, &cfg, WithRawSources(s1))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Nil(t, s1.ctxValue)
+
+	// tree path sources stop as soon as the context is done
+	setter := configTreeSetter{ctx: ctx, src: s2}
+	err = setter.setValues(&cfg)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
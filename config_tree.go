@@ -0,0 +1,281 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TreeLeaf describes a leaf of the config tree, which is
+// a value sources are asked for, like a string or a slice.
+type TreeLeaf struct {
+	// TreePath is the config tree path of the leaf.
+	TreePath string
+	// Type is the type of the leaf.
+	Type reflect.Type
+	// Fields contains the struct fields that lead to the
+	// leaf, from the root of the config to the leaf itself.
+	Fields []reflect.StructField
+}
+
+// WalkTreeLeaves calls fn for each leaf of the config tree of typ, in fields
+// order. Pointers are followed unless a decoder is registered for them, and structs
+// are walked through unless they have a string decoder (see HasStringDecoder).
+// Slices, arrays and maps are leaves: their elements can't be known from the type.
+// Recursive types are only walked through once per branch.
+func WalkTreeLeaves(typ reflect.Type, fn func(leaf TreeLeaf)) {
+	if typ == nil {
+		return
+	}
+	walkTreeLeaves("", typ, nil, make(map[reflect.Type]bool), fn)
+}
+
+func walkTreeLeaves(
+	path string, typ reflect.Type, fields []reflect.StructField,
+	walking map[reflect.Type]bool, fn func(leaf TreeLeaf),
+) {
+	for typ.Kind() == reflect.Ptr {
+		// pointers with a decoder registered for them are leaves
+		if _, exists := lookupDecoder(typ); exists {
+			break
+		}
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() != reflect.Struct || HasStringDecoder(typ) {
+		fn(TreeLeaf{TreePath: path, Type: typ, Fields: fields})
+		return
+	}
+
+	if walking[typ] {
+		return
+	}
+	walking[typ] = true
+	defer delete(walking, typ)
+
+	for i := 0; i < typ.NumField(); i++ {
+		var field = typ.Field(i)
+
+		childPath, ok := configTreeFieldPath(path, field)
+		if !ok {
+			continue
+		}
+
+		// fields are copied to not share the backing array between branches
+		childFields := make([]reflect.StructField, len(fields), len(fields)+1)
+		copy(childFields, fields)
+
+		walkTreeLeaves(childPath, field.Type, append(childFields, field), walking, fn)
+	}
+}
+
+// Get returns the value of cfg at the config tree path, resolved with the same
+// naming rules as the loader: path parts are lowercased field names or `cfg` tag
+// names, indexes of slices and arrays, or keys of maps. The empty path returns cfg.
+// Values behind nil pointers, and missing map keys, are returned as zero values.
+func Get(cfg interface{}, treePath string) (interface{}, error) {
+	var v = reflect.ValueOf(cfg)
+	if !v.IsValid() {
+		return nil, errors.New("cfg is nil")
+	}
+
+	for _, part := range splitConfigTreePath(treePath) {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v = reflect.Zero(v.Type().Elem())
+			} else {
+				v = v.Elem()
+			}
+		}
+
+		child, err := configTreeChild(v, part)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get %q: %w", treePath, err)
+		}
+		v = child
+	}
+
+	return v.Interface(), nil
+}
+
+// Set sets the value of cfg, which must be a pointer, at the config tree
+// path (see Get for the naming rules). Nil pointers and maps are allocated,
+// and slices grown, as needed. The value is used as is if it is assignable
+// to the destination, otherwise strings are parsed with the same rules as
+// the env source (see InitializeNewValueOfTypeWithString). The path and the
+// value are checked first, cfg is left untouched if any of them is invalid.
+func Set(cfg interface{}, treePath string, value interface{}) error {
+	var v = reflect.ValueOf(cfg)
+	if !v.IsValid() || v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("cfg must be a non-nil pointer")
+	}
+
+	var parts = splitConfigTreePath(treePath)
+
+	if err := checkConfigTreeValue(v.Elem().Type(), parts, value); err != nil {
+		return fmt.Errorf("unable to set %q: %w", treePath, err)
+	}
+
+	if err := setConfigTreeValue(v.Elem(), parts, value); err != nil {
+		return fmt.Errorf("unable to set %q: %w", treePath, err)
+	}
+
+	return nil
+}
+
+func splitConfigTreePath(treePath string) []string {
+	if treePath == "" {
+		return nil
+	}
+	return strings.Split(strings.ToLower(treePath), ".")
+}
+
+// configTreeChild returns the child of v named part. v must not be a pointer.
+func configTreeChild(v reflect.Value, part string) (reflect.Value, error) {
+	switch v.Kind() {
+	case reflect.Struct:
+		if HasStringDecoder(v.Type()) {
+			break
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if name, ok := configTreeFieldPath("", v.Type().Field(i)); ok && name == part {
+				return v.Field(i), nil
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		index, err := strconv.Atoi(part)
+		if err != nil || index < 0 {
+			return reflect.Value{}, fmt.Errorf("%q is not a valid index", part)
+		}
+		if index >= v.Len() {
+			return reflect.Value{}, fmt.Errorf("index %d is out of range", index)
+		}
+		return v.Index(index), nil
+	case reflect.Map:
+		key, err := InitializeNewValueOfTypeWithString(v.Type().Key(), part)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%q is not a valid key: %w", part, err)
+		}
+		if elem := v.MapIndex(*key); elem.IsValid() {
+			return elem, nil
+		}
+		return reflect.Zero(v.Type().Elem()), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("%q does not exist in %s", part, v.Type())
+}
+
+// checkConfigTreeValue checks, without any value to modify, that the path made
+// of parts exists in typ and that value can be set at its end.
+func checkConfigTreeValue(typ reflect.Type, parts []string, value interface{}) error {
+	if len(parts) == 0 {
+		return setConfigTreeLeaf(reflect.New(typ).Elem(), value)
+	}
+
+	switch typ.Kind() {
+	case reflect.Ptr:
+		return checkConfigTreeValue(typ.Elem(), parts, value)
+	case reflect.Slice, reflect.Array:
+		index, err := strconv.Atoi(parts[0])
+		if err != nil || index < 0 {
+			return fmt.Errorf("%q is not a valid index", parts[0])
+		}
+		if typ.Kind() == reflect.Array && index >= typ.Len() {
+			return fmt.Errorf("index %d is out of range", index)
+		}
+	case reflect.Map:
+		if _, err := InitializeNewValueOfTypeWithString(typ.Key(), parts[0]); err != nil {
+			return fmt.Errorf("%q is not a valid key: %w", parts[0], err)
+		}
+	case reflect.Struct:
+		if HasStringDecoder(typ) {
+			return fmt.Errorf("%q does not exist in %s", parts[0], typ)
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			if name, ok := configTreeFieldPath("", typ.Field(i)); ok && name == parts[0] {
+				return checkConfigTreeValue(typ.Field(i).Type, parts[1:], value)
+			}
+		}
+		return fmt.Errorf("%q does not exist in %s", parts[0], typ)
+	default:
+		return fmt.Errorf("%q does not exist in %s", parts[0], typ)
+	}
+
+	return checkConfigTreeValue(typ.Elem(), parts[1:], value)
+}
+
+// setConfigTreeValue sets value in v, which must be settable, at the path made of parts.
+func setConfigTreeValue(v reflect.Value, parts []string, value interface{}) error {
+	if len(parts) == 0 {
+		return setConfigTreeLeaf(v, value)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setConfigTreeValue(v.Elem(), parts, value)
+	case reflect.Slice:
+		index, err := strconv.Atoi(parts[0])
+		if err != nil || index < 0 {
+			return fmt.Errorf("%q is not a valid index", parts[0])
+		}
+		if index >= v.Len() {
+			newV := reflect.MakeSlice(v.Type(), index+1, index+1)
+			reflect.Copy(newV, v)
+			v.Set(newV)
+		}
+		return setConfigTreeValue(v.Index(index), parts[1:], value)
+	case reflect.Map:
+		key, err := InitializeNewValueOfTypeWithString(v.Type().Key(), parts[0])
+		if err != nil {
+			return fmt.Errorf("%q is not a valid key: %w", parts[0], err)
+		}
+
+		// map elements are not addressable, a copy is set instead
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(*key); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := setConfigTreeValue(elem, parts[1:], value); err != nil {
+			return err
+		}
+
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		v.SetMapIndex(*key, elem)
+
+		return nil
+	default:
+		child, err := configTreeChild(v, parts[0])
+		if err != nil {
+			return err
+		}
+		return setConfigTreeValue(child, parts[1:], value)
+	}
+}
+
+func setConfigTreeLeaf(v reflect.Value, value interface{}) error {
+	var newV = reflect.ValueOf(value)
+
+	switch {
+	case !newV.IsValid():
+		newV = reflect.Zero(v.Type())
+	case newV.Type().AssignableTo(v.Type()):
+	case newV.Kind() == reflect.String:
+		parsed, err := InitializeNewValueOfTypeWithString(v.Type(), newV.String())
+		if err != nil {
+			return err
+		}
+		newV = *parsed
+	default:
+		return fmt.Errorf("value of type %s can't be assigned to %s", newV.Type(), v.Type())
+	}
+
+	_, err := SetNewValue(&v, &newV)
+	return err
+}
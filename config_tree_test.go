@@ -0,0 +1,168 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WalkTreeLeaves(t *testing.T) {
+	type (
+		recursive struct {
+			Name string
+			Next *recursive
+		}
+
+		icfg struct {
+			Name     string `cfg:"renamed"`
+			HTTP     *struct{ ListenAddress string }
+			Endpoint url.URL
+			Hosts    []string
+			Nodes    recursive
+			Ignored  string `cfg:"-"`
+			private  string // nolint: structcheck, unused
+		}
+	)
+
+	var leaves []TreeLeaf
+	WalkTreeLeaves(reflect.TypeOf(&icfg{}), func(leaf TreeLeaf) {
+		leaves = append(leaves, leaf)
+	})
+
+	var paths, names []string
+	for _, leaf := range leaves {
+		paths = append(paths, leaf.TreePath)
+
+		var name string
+		for _, field := range leaf.Fields {
+			name += "/" + field.Name
+		}
+		names = append(names, name)
+	}
+
+	assert.Equal(t, []string{
+		"renamed", "http.listenaddress", "endpoint", "hosts", "nodes.name",
+	}, paths)
+	assert.Equal(t, []string{
+		"/Name", "/HTTP/ListenAddress", "/Endpoint", "/Hosts", "/Nodes/Name",
+	}, names)
+	assert.Equal(t, reflect.TypeOf(url.URL{}), leaves[2].Type)
+	assert.Equal(t, reflect.TypeOf([]string{}), leaves[3].Type)
+
+	leaves = nil
+	WalkTreeLeaves(reflect.TypeOf(42), func(leaf TreeLeaf) { leaves = append(leaves, leaf) })
+	assert.Equal(t, []TreeLeaf{{Type: reflect.TypeOf(42)}}, leaves)
+
+	WalkTreeLeaves(nil, func(TreeLeaf) { t.Fatal("should not be called") })
+}
+
+type getSetCfg struct {
+	HTTP struct {
+		ListenAddress string `cfg:"addr"`
+		Timeout       time.Duration
+	}
+	Database *struct{ Host string }
+	Endpoint url.URL
+	Servers  []struct{ Host string }
+	Hosts    [2]string
+	Labels   map[string]string
+	Ports    map[int]struct{ Name string }
+	Ignored  string `cfg:"-"`
+}
+
+func Test_Get(t *testing.T) {
+	var cfg getSetCfg
+	cfg.HTTP.ListenAddress = ":8080"
+	cfg.Servers = []struct{ Host string }{{Host: "a"}}
+	cfg.Labels = map[string]string{"env": "prod"}
+
+	var tests = map[string]struct {
+		treePath        string
+		expected        interface{}
+		expectedFailure bool
+	}{
+		"root":                      {treePath: "", expected: &cfg},
+		"renamed field":             {treePath: "HTTP.addr", expected: ":8080"},
+		"struct":                    {treePath: "http", expected: cfg.HTTP},
+		"behind nil pointer":        {treePath: "database.host", expected: ""},
+		"slice element":             {treePath: "servers.0.host", expected: "a"},
+		"array element":             {treePath: "hosts.1", expected: ""},
+		"map element":               {treePath: "labels.env", expected: "prod"},
+		"missing map element":       {treePath: "ports.80.name", expected: ""},
+		"original field name":       {treePath: "http.listenaddress", expectedFailure: true},
+		"ignored field":             {treePath: "ignored", expectedFailure: true},
+		"inside a decodable struct": {treePath: "endpoint.host", expectedFailure: true},
+		"slice index out of range":  {treePath: "servers.1", expectedFailure: true},
+		"invalid slice index":       {treePath: "servers.a", expectedFailure: true},
+		"invalid map key":           {treePath: "ports.http", expectedFailure: true},
+		"inside a leaf":             {treePath: "http.addr.port", expectedFailure: true},
+		"unknown":                   {treePath: "unknown", expectedFailure: true},
+	}
+
+	for name, test := range tests {
+		var test = test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := Get(&cfg, test.treePath)
+			if test.expectedFailure {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, value)
+		})
+	}
+
+	_, err := Get(nil, "")
+	require.Error(t, err)
+}
+
+func Test_Set(t *testing.T) {
+	var cfg getSetCfg
+
+	require.NoError(t, Set(&cfg, "http.addr", ":9000"))
+	require.NoError(t, Set(&cfg, "http.timeout", "3s"))
+	require.NoError(t, Set(&cfg, "database.host", "db"))
+	require.NoError(t, Set(&cfg, "endpoint", url.URL{Host: "localhost"}))
+	require.NoError(t, Set(&cfg, "servers.1.host", "b"))
+	require.NoError(t, Set(&cfg, "hosts.1", "h"))
+	require.NoError(t, Set(&cfg, "labels.env", "prod"))
+	require.NoError(t, Set(&cfg, "ports.80.name", "http"))
+
+	assert.Equal(t, ":9000", cfg.HTTP.ListenAddress)
+	assert.Equal(t, 3*time.Second, cfg.HTTP.Timeout)
+	assert.Equal(t, "db", cfg.Database.Host)
+	assert.Equal(t, "localhost", cfg.Endpoint.Host)
+	assert.Equal(t, []struct{ Host string }{{}, {Host: "b"}}, cfg.Servers)
+	assert.Equal(t, [2]string{"", "h"}, cfg.Hosts)
+	assert.Equal(t, map[string]string{"env": "prod"}, cfg.Labels)
+	assert.Equal(t, map[int]struct{ Name string }{80: {Name: "http"}}, cfg.Ports)
+
+	require.NoError(t, Set(&cfg, "http.timeout", time.Minute))
+	assert.Equal(t, time.Minute, cfg.HTTP.Timeout)
+	require.NoError(t, Set(&cfg, "database", nil))
+	assert.Nil(t, cfg.Database)
+
+	require.Error(t, Set(cfg, "http.addr", ":9000"))
+	require.Error(t, Set(&cfg, "http.timeout", "not a duration"))
+	require.Error(t, Set(&cfg, "http.timeout", true))
+	require.Error(t, Set(&cfg, "unknown", "value"))
+	require.Error(t, Set(&cfg, "servers.a.host", "value"))
+	require.Error(t, Set(&cfg, "hosts.2", "value"))
+	require.Error(t, Set(&cfg, "ports.http.name", "value"))
+	require.Error(t, Set(&cfg, "ports.80.unknown", "value"))
+	assert.Equal(t, map[int]struct{ Name string }{80: {Name: "http"}}, cfg.Ports)
+
+	// invalid paths and values leave the configuration untouched
+	cfg = getSetCfg{}
+	require.Error(t, Set(&cfg, "database.unknown", "value"))
+	require.Error(t, Set(&cfg, "database.host", true))
+	require.Error(t, Set(&cfg, "servers.5.unknown", "value"))
+	require.Error(t, Set(&cfg, "labels.env.name", "value"))
+	assert.Equal(t, getSetCfg{}, cfg)
+}
@@ -0,0 +1,63 @@
+package configtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// Flatten returns the leaves of the values tree given to
+// factories (see SourceFactory) indexed by config tree path.
+func Flatten(values map[string]interface{}) map[string]string {
+	var flat = make(map[string]string)
+	flatten("", values, flat)
+	return flat
+}
+
+func flatten(parentPath string, values map[string]interface{}, flat map[string]string) {
+	for key, value := range values {
+		var treePath = key
+		if parentPath != "" {
+			treePath = parentPath + "." + key
+		}
+
+		if children, ok := value.(map[string]interface{}); ok {
+			flatten(treePath, children, flat)
+			continue
+		}
+		flat[treePath] = fmt.Sprint(value)
+	}
+}
+
+// Setenv sets the environment variable key for the duration of the
+// test. The previous value, if any, is restored once the test ends.
+func Setenv(t testing.TB, key, value string) {
+	t.Helper()
+
+	previous, existed := os.LookupEnv(key)
+	require.NoError(t, os.Setenv(key, value))
+
+	t.Cleanup(func() {
+		if existed {
+			_ = os.Setenv(key, previous)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
+
+// WriteFile writes the file on fs for the duration of the test, creating
+// its parent directories if needed. The file is removed once the test ends.
+// Use an in-memory fs (afero.NewMemMapFs) to avoid touching the disk.
+func WriteFile(t testing.TB, fs afero.Fs, path, content string) {
+	t.Helper()
+
+	require.NoError(t, fs.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, afero.WriteFile(fs, path, []byte(content), 0600))
+
+	t.Cleanup(func() { _ = fs.Remove(path) })
+}
@@ -0,0 +1,61 @@
+package configtest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Flatten(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, map[string]string{
+		"a":     "1",
+		"b.c":   "true",
+		"b.d.e": "hello",
+	}, Flatten(map[string]interface{}{
+		"a": 1,
+		"b": map[string]interface{}{
+			"c": true,
+			"d": map[string]interface{}{"e": "hello"},
+		},
+	}))
+	assert.Empty(t, Flatten(nil))
+}
+
+func Test_Setenv(t *testing.T) {
+	require.NoError(t, os.Setenv("CONFIGTEST_EXISTING", "before"))
+	defer os.Unsetenv("CONFIGTEST_EXISTING") // nolint: errcheck
+
+	t.Run("set", func(t *testing.T) {
+		Setenv(t, "CONFIGTEST_EXISTING", "during")
+		Setenv(t, "CONFIGTEST_NEW", "during")
+		assert.Equal(t, "during", os.Getenv("CONFIGTEST_EXISTING"))
+		assert.Equal(t, "during", os.Getenv("CONFIGTEST_NEW"))
+	})
+
+	assert.Equal(t, "before", os.Getenv("CONFIGTEST_EXISTING"))
+	_, exists := os.LookupEnv("CONFIGTEST_NEW")
+	assert.False(t, exists)
+}
+
+func Test_WriteFile(t *testing.T) {
+	t.Parallel()
+
+	var fs = afero.NewMemMapFs()
+
+	t.Run("write", func(t *testing.T) {
+		WriteFile(t, fs, "dir/file.yaml", "hello: world")
+
+		raw, err := afero.ReadFile(fs, "dir/file.yaml")
+		require.NoError(t, err)
+		assert.Equal(t, "hello: world", string(raw))
+	})
+
+	exists, err := afero.Exists(fs, "dir/file.yaml")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,126 @@
+// Package configtest provides helpers to test configuration sources.
+//
+// RunSourceSuite runs a conformance suite against any source implementing
+// one of the load interfaces, to check it behaves like the sources of
+// this module do:
+//
+//	func TestSource_conformance(t *testing.T) {
+//		configtest.RunSourceSuite(t, func(t *testing.T, values map[string]interface{}) config.Source {
+//			for treePath, value := range configtest.Flatten(values) {
+//				configtest.Setenv(t, "MYAPP_"+strings.ToUpper(strings.ReplaceAll(treePath, ".", "_")), value)
+//			}
+//			source, err := sourceenv.New("myapp")()
+//			require.NoError(t, err)
+//			return source
+//		})
+//	}
+package configtest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/krostar/config"
+)
+
+// SourceFactory creates the source to test. The source must provide the
+// given values, organized as a tree whose keys are config tree path parts
+// (lowercased field names), and whose leaves are strings, ints or bools.
+// Durations are given as strings, like "3s". Flatten can be used
+// to get values by config tree path instead.
+type SourceFactory func(t *testing.T, values map[string]interface{}) config.Source
+
+// SuiteConfig is the configuration loaded by RunSourceSuite.
+type SuiteConfig struct {
+	Name     string
+	Port     int
+	Debug    bool
+	Timeout  time.Duration
+	Database SuiteDatabase
+	Cache    *SuiteDatabase
+}
+
+// SuiteDatabase is a nested structure of SuiteConfig.
+type SuiteDatabase struct {
+	Host string
+	Port int
+}
+
+// RunSourceSuite runs, as sub tests, the conformance suite against
+// the sources created by factory. Sub tests are not run in parallel,
+// which allows factories to use process-wide resources, like env vars.
+func RunSourceSuite(t *testing.T, factory SourceFactory) {
+	t.Run("values are set", func(t *testing.T) {
+		var cfg SuiteConfig
+
+		source := factory(t, map[string]interface{}{
+			"name":     "app",
+			"port":     8080,
+			"debug":    true,
+			"timeout":  "3s",
+			"database": map[string]interface{}{"host": "db", "port": 5432},
+			"cache":    map[string]interface{}{"host": "cache"},
+		})
+
+		require.NoError(t, config.Load(&cfg, config.WithRawSources(source)))
+		assert.Equal(t, SuiteConfig{
+			Name:     "app",
+			Port:     8080,
+			Debug:    true,
+			Timeout:  3 * time.Second,
+			Database: SuiteDatabase{Host: "db", Port: 5432},
+			Cache:    &SuiteDatabase{Host: "cache"},
+		}, cfg)
+	})
+
+	t.Run("pointers are only allocated when needed", func(t *testing.T) {
+		var cfg SuiteConfig
+
+		source := factory(t, map[string]interface{}{"name": "app"})
+
+		require.NoError(t, config.Load(&cfg, config.WithRawSources(source)))
+		assert.Equal(t, SuiteConfig{Name: "app"}, cfg)
+	})
+
+	t.Run("missing keys keep existing values", func(t *testing.T) {
+		var cfg = SuiteConfig{
+			Name:     "app",
+			Port:     8080,
+			Database: SuiteDatabase{Host: "db"},
+			Cache:    &SuiteDatabase{Port: 6379},
+		}
+
+		source := factory(t, map[string]interface{}{
+			"database": map[string]interface{}{"port": 5432},
+		})
+
+		require.NoError(t, config.Load(&cfg, config.WithRawSources(source)))
+		assert.Equal(t, SuiteConfig{
+			Name:     "app",
+			Port:     8080,
+			Database: SuiteDatabase{Host: "db", Port: 5432},
+			Cache:    &SuiteDatabase{Port: 6379},
+		}, cfg)
+	})
+
+	t.Run("invalid values fail", func(t *testing.T) {
+		var cfg SuiteConfig
+
+		source := factory(t, map[string]interface{}{"port": "not a number"})
+
+		require.Error(t, config.Load(&cfg, config.WithRawSources(source)))
+
+		err := config.Load(&cfg, config.WithRawSources(source), config.WithErrorsAggregation())
+		require.Error(t, err)
+
+		var errs config.LoadErrors
+		require.True(t, errors.As(err, &errs), "errors should be wrapped in config.LoadErrors")
+		require.NotEmpty(t, errs)
+		assert.Equal(t, source.Name(), errs[0].Source)
+		assert.NotNil(t, errors.Unwrap(errs[0]), "load error should wrap the source error")
+	})
+}
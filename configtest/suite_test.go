@@ -0,0 +1,34 @@
+package configtest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/krostar/config"
+	"github.com/krostar/config/trivialerr"
+)
+
+// stubSource sets values by config tree path, like the env source does.
+type stubSource map[string]string
+
+func (s stubSource) Name() string { return "stub" }
+
+func (s stubSource) SetValueFromConfigTreePath(v *reflect.Value, treePath string) (bool, error) {
+	str, found := s[treePath]
+	if !found {
+		return false, trivialerr.ErrNotFound
+	}
+
+	newV, err := config.InitializeNewValueOfTypeWithString(v.Type(), str)
+	if err != nil {
+		return false, err
+	}
+
+	return config.SetNewValue(v, newV)
+}
+
+func Test_RunSourceSuite(t *testing.T) {
+	RunSourceSuite(t, func(t *testing.T, values map[string]interface{}) config.Source {
+		return stubSource(Flatten(values))
+	})
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// DecodeError is returned when a raw value can't be decoded to a type.
+type DecodeError struct {
+	// Type is the type the raw value should have been decoded to.
+	Type reflect.Type
+	// RawValue is the value that failed to be decoded.
+	RawValue string
+	// Err is the reason of the failure.
+	Err error
+}
+
+// Error implements error.
+func (e *DecodeError) Error() string {
+	if _, isRedacted := e.Err.(redactedError); isRedacted {
+		return fmt.Sprintf("unable to decode redacted value to %s", e.Type)
+	}
+	return fmt.Sprintf("unable to decode %q to %s: %s", e.RawValue, e.Type, e.Err)
+}
+
+// Redacted returns a copy of the error without the raw value, for values that
+// must not be leaked, like secrets. As the reason of the failure usually contains
+// the raw value too, it is not part of the message anymore, but can still be
+// matched with errors.Is and errors.As.
+func (e *DecodeError) Redacted() *DecodeError {
+	return &DecodeError{Type: e.Type, Err: redactedError{err: e.Err}}
+}
+
+// Unwrap implements errors.Unwrap.
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// RedactDecodeError helps sources that read values that must not be leaked, like
+// secrets: decoding errors are redacted (see DecodeError.Redacted), and the message
+// of any other error is hidden, while the error can still be matched.
+func RedactDecodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		return decodeErr.Redacted()
+	}
+
+	return redactedError{err: err}
+}
+
+// redactedError hides the message of an error, but not the error itself.
+type redactedError struct{ err error }
+
+func (e redactedError) Error() string { return "redacted" }
+
+func (e redactedError) Unwrap() error { return e.err }
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeError_Error(t *testing.T) {
+	var (
+		reason = errors.New("boum")
+		err    = &DecodeError{Type: reflect.TypeOf(0), RawValue: "hello", Err: reason}
+	)
+
+	assert.Equal(t, `unable to decode "hello" to int: boum`, err.Error())
+	assert.Equal(t, reason, err.Unwrap())
+}
+
+func TestDecodeError_Redacted(t *testing.T) {
+	var (
+		reason = errors.New(`"hello" is not a number`)
+		err    = (&DecodeError{Type: reflect.TypeOf(0), RawValue: "hello", Err: reason}).Redacted()
+	)
+
+	assert.Equal(t, "unable to decode redacted value to int", err.Error())
+	assert.Empty(t, err.RawValue)
+	assert.True(t, errors.Is(err, reason))
+	assert.NotContains(t, err.Unwrap().Error(), "hello")
+}
+
+func Test_RedactDecodeError(t *testing.T) {
+	assert.NoError(t, RedactDecodeError(nil))
+
+	var (
+		reason    = errors.New(`"hello" is not a number`)
+		decodeErr = &DecodeError{Type: reflect.TypeOf(0), RawValue: "hello", Err: reason}
+	)
+
+	err := RedactDecodeError(fmt.Errorf("wrapped: %w", decodeErr))
+	assert.Equal(t, "unable to decode redacted value to int", err.Error())
+	assert.True(t, errors.Is(err, reason))
+
+	err = RedactDecodeError(reason)
+	assert.Equal(t, "redacted", err.Error())
+	assert.True(t, errors.Is(err, reason))
+}
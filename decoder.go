@@ -0,0 +1,237 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DecoderFunc creates a value from its string representation.
+type DecoderFunc func(str string) (interface{}, error)
+
+// decoders contains all registered decoders, by type.
+var decoders = struct {
+	sync.RWMutex
+	byType map[reflect.Type]DecoderFunc
+}{byType: make(map[reflect.Type]DecoderFunc)}
+
+// RegisterDecoder registers a decoder for the provided type. It will then be used
+// by InitializeNewValueOfTypeWithString, and InitializeNewValueOfTypeWithJSON when
+// the json value is a string, to create values of this type, or of a pointer to
+// this type. The decoder must return a value assignable or convertible to the type.
+// Registering a decoder for an already registered type replaces it.
+func RegisterDecoder(typ reflect.Type, decoder DecoderFunc) {
+	decoders.Lock()
+	defer decoders.Unlock()
+	decoders.byType[typ] = decoder
+}
+
+func lookupDecoder(typ reflect.Type) (DecoderFunc, bool) {
+	decoders.RLock()
+	defer decoders.RUnlock()
+	decoder, exists := decoders.byType[typ]
+	return decoder, exists
+}
+
+// HasStringDecoder returns true if values of the provided type are created by
+// InitializeNewValueOfTypeWithString through a decoder registered for the type,
+// the pointed type or a pointer to the type, or through the encoding.TextUnmarshaler
+// or the flag.Value interface, instead of being decoded from their json representation.
+func HasStringDecoder(typ reflect.Type) bool {
+	var elemTyp = typ
+	if typ.Kind() == reflect.Ptr {
+		elemTyp = typ.Elem()
+	}
+
+	if _, exists := lookupDecoder(typ); exists {
+		return true
+	}
+	if _, exists := lookupDecoder(elemTyp); exists {
+		return true
+	}
+	if _, exists := lookupDecoder(reflect.PtrTo(elemTyp)); exists {
+		return true
+	}
+
+	ptrTyp := reflect.PtrTo(elemTyp)
+	return ptrTyp.Implements(textUnmarshalerType) || ptrTyp.Implements(flagValueType)
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	flagValueType       = reflect.TypeOf((*flag.Value)(nil)).Elem()
+)
+
+// decodeWithRegisteredDecoder creates a new value of the provided type using the
+// decoder registered for it, for the pointed type, or for a pointer to the type.
+// It returns false if no decoder is registered.
+func decodeWithRegisteredDecoder(typ reflect.Type, str string) (*reflect.Value, bool, error) {
+	if decoder, exists := lookupDecoder(typ); exists {
+		v, err := decodeWith(decoder, typ, str)
+		return v, true, err
+	}
+
+	// a decoder may be registered for the pointed type
+	if typ.Kind() == reflect.Ptr {
+		if decoder, exists := lookupDecoder(typ.Elem()); exists {
+			pv, err := decodeWith(decoder, typ.Elem(), str)
+			if err != nil {
+				return nil, true, err
+			}
+
+			v := reflect.New(typ.Elem())
+			v.Elem().Set(*pv)
+			return &v, true, nil
+		}
+	}
+
+	// or for a pointer to the type
+	if typ.Kind() != reflect.Ptr {
+		if decoder, exists := lookupDecoder(reflect.PtrTo(typ)); exists {
+			pv, err := decodeWith(decoder, reflect.PtrTo(typ), str)
+			if err != nil {
+				return nil, true, err
+			}
+			if pv.IsNil() {
+				return nil, true, errors.New("decoder returned a nil value")
+			}
+
+			v := pv.Elem()
+			return &v, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// decodeWithTextUnmarshaler creates a new value of the provided type using the
+// encoding.TextUnmarshaler or the flag.Value interface, if implemented by a pointer
+// to the type, or by the pointer itself. It returns false if none are implemented.
+func decodeWithTextUnmarshaler(typ reflect.Type, str string) (*reflect.Value, bool, error) {
+	var elemTyp = typ
+	if typ.Kind() == reflect.Ptr {
+		elemTyp = typ.Elem()
+	}
+
+	var (
+		ptr = reflect.New(elemTyp)
+		err error
+	)
+
+	switch u := ptr.Interface().(type) {
+	case encoding.TextUnmarshaler:
+		err = u.UnmarshalText([]byte(str))
+	case flag.Value:
+		err = u.Set(str)
+	default:
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, true, err
+	}
+
+	if typ.Kind() == reflect.Ptr {
+		return &ptr, true, nil
+	}
+
+	v := ptr.Elem()
+	return &v, true, nil
+}
+
+func decodeWith(decoder DecoderFunc, typ reflect.Type, str string) (*reflect.Value, error) {
+	decoded, err := decoder(str)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		v        = reflect.New(typ).Elem()
+		decodedV = reflect.ValueOf(decoded)
+	)
+
+	switch {
+	case !decodedV.IsValid():
+		return nil, errors.New("decoder returned a nil value")
+	case decodedV.Type().AssignableTo(typ):
+		v.Set(decodedV)
+	case decodedV.Type().ConvertibleTo(typ):
+		v.Set(decodedV.Convert(typ))
+	default:
+		return nil, fmt.Errorf("decoder returned a value of type %s instead of %s", decodedV.Type(), typ)
+	}
+
+	return &v, nil
+}
+
+func init() { // nolint: gochecknoinits
+	RegisterDecoder(reflect.TypeOf(url.URL{}), func(str string) (interface{}, error) {
+		u, err := url.Parse(str)
+		if err != nil {
+			return nil, err
+		}
+		return *u, nil
+	})
+
+	RegisterDecoder(reflect.TypeOf(net.IP{}), func(str string) (interface{}, error) {
+		ip := net.ParseIP(str)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid ip address %q", str)
+		}
+		return ip, nil
+	})
+
+	RegisterDecoder(reflect.TypeOf(net.IPNet{}), func(str string) (interface{}, error) {
+		_, ipNet, err := net.ParseCIDR(str)
+		if err != nil {
+			return nil, err
+		}
+		return *ipNet, nil
+	})
+
+	RegisterDecoder(reflect.TypeOf(&regexp.Regexp{}), func(str string) (interface{}, error) {
+		return regexp.Compile(str)
+	})
+
+	RegisterDecoder(reflect.TypeOf(time.Time{}), func(str string) (interface{}, error) {
+		return time.Parse(time.RFC3339Nano, str)
+	})
+
+	RegisterDecoder(reflect.TypeOf(&time.Location{}), func(str string) (interface{}, error) {
+		return time.LoadLocation(str)
+	})
+
+	RegisterDecoder(reflect.TypeOf(time.Location{}), func(str string) (interface{}, error) {
+		location, err := time.LoadLocation(str)
+		if err != nil {
+			return nil, err
+		}
+		return *location, nil
+	})
+
+	RegisterDecoder(reflect.TypeOf(os.FileMode(0)), func(str string) (interface{}, error) {
+		mode, err := strconv.ParseUint(str, 8, 32)
+		if err != nil {
+			return nil, err
+		}
+		return os.FileMode(mode), nil
+	})
+
+	RegisterDecoder(reflect.TypeOf(big.Int{}), func(str string) (interface{}, error) {
+		i, ok := new(big.Int).SetString(str, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer %q", str)
+		}
+		return *i, nil
+	})
+}
@@ -0,0 +1,290 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_builtinDecoders(t *testing.T) {
+	var (
+		u, _        = url.Parse("https://example.com/path?q=1")
+		_, ipNet, _ = net.ParseCIDR("10.0.0.0/8")
+		date, _     = time.Parse(time.RFC3339, "2020-04-01T10:00:00Z")
+		location, _ = time.LoadLocation("UTC")
+		bigInt, _   = new(big.Int).SetString("123456789012345678901234567890", 10)
+	)
+
+	var tests = map[string]struct {
+		valueRepr       string
+		valueType       reflect.Type
+		expectedValue   interface{}
+		expectedFailure bool
+	}{
+		"url": {
+			valueRepr:     "https://example.com/path?q=1",
+			valueType:     reflect.TypeOf(url.URL{}),
+			expectedValue: *u,
+		},
+		"url pointer": {
+			valueRepr:     "https://example.com/path?q=1",
+			valueType:     reflect.TypeOf(&url.URL{}),
+			expectedValue: *u,
+		},
+		"invalid url": {
+			valueRepr:       "://",
+			valueType:       reflect.TypeOf(url.URL{}),
+			expectedFailure: true,
+		},
+		"ip": {
+			valueRepr:     "192.168.0.1",
+			valueType:     reflect.TypeOf(net.IP{}),
+			expectedValue: net.ParseIP("192.168.0.1"),
+		},
+		"invalid ip": {
+			valueRepr:       "192.168.0",
+			valueType:       reflect.TypeOf(net.IP{}),
+			expectedFailure: true,
+		},
+		"ip network": {
+			valueRepr:     "10.1.2.3/8",
+			valueType:     reflect.TypeOf(net.IPNet{}),
+			expectedValue: *ipNet,
+		},
+		"invalid ip network": {
+			valueRepr:       "10.1.2.3",
+			valueType:       reflect.TypeOf(net.IPNet{}),
+			expectedFailure: true,
+		},
+		"regexp": {
+			valueRepr:     "^a+$",
+			valueType:     reflect.TypeOf(&regexp.Regexp{}),
+			expectedValue: *regexp.MustCompile("^a+$"),
+		},
+		"invalid regexp": {
+			valueRepr:       "^a+($",
+			valueType:       reflect.TypeOf(&regexp.Regexp{}),
+			expectedFailure: true,
+		},
+		"time": {
+			valueRepr:     "2020-04-01T10:00:00Z",
+			valueType:     reflect.TypeOf(time.Time{}),
+			expectedValue: date,
+		},
+		"invalid time": {
+			valueRepr:       "2020-04-01",
+			valueType:       reflect.TypeOf(time.Time{}),
+			expectedFailure: true,
+		},
+		"location pointer": {
+			valueRepr:     "UTC",
+			valueType:     reflect.TypeOf(&time.Location{}),
+			expectedValue: *location,
+		},
+		"location": {
+			valueRepr:     "UTC",
+			valueType:     reflect.TypeOf(time.Location{}),
+			expectedValue: *location,
+		},
+		"invalid location": {
+			valueRepr:       "Nowhere/Somewhere",
+			valueType:       reflect.TypeOf(&time.Location{}),
+			expectedFailure: true,
+		},
+		"file mode": {
+			valueRepr:     "0644",
+			valueType:     reflect.TypeOf(os.FileMode(0)),
+			expectedValue: os.FileMode(0644),
+		},
+		"invalid file mode": {
+			valueRepr:       "0999",
+			valueType:       reflect.TypeOf(os.FileMode(0)),
+			expectedFailure: true,
+		},
+		"big int": {
+			valueRepr:     "123456789012345678901234567890",
+			valueType:     reflect.TypeOf(big.Int{}),
+			expectedValue: *bigInt,
+		},
+		"invalid big int": {
+			valueRepr:       "1.5",
+			valueType:       reflect.TypeOf(big.Int{}),
+			expectedFailure: true,
+		},
+	}
+
+	for name, test := range tests {
+		var test = test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for repr, initialize := range map[string]func() (*reflect.Value, error){
+				"string": func() (*reflect.Value, error) {
+					return InitializeNewValueOfTypeWithString(test.valueType, test.valueRepr)
+				},
+				"json": func() (*reflect.Value, error) {
+					return InitializeNewValueOfTypeWithJSON(test.valueType, []byte(strconv.Quote(test.valueRepr)))
+				},
+			} {
+				value, err := initialize()
+				if test.expectedFailure {
+					require.Error(t, err, repr)
+					var decodeErr *DecodeError
+					assert.True(t, errors.As(err, &decodeErr), repr)
+				} else {
+					require.NoError(t, err, repr)
+					require.Equal(t, test.valueType, value.Type(), repr)
+					assert.Equal(t, test.expectedValue, reflect.Indirect(*value).Interface(), repr)
+				}
+			}
+		})
+	}
+}
+
+func Test_RegisterDecoder(t *testing.T) {
+	type (
+		registered        struct{ value string }
+		registeredPointer struct{ value string }
+		convertible       int
+		unassignable      int
+	)
+
+	RegisterDecoder(reflect.TypeOf(registered{}), func(str string) (interface{}, error) {
+		if str == "" {
+			return nil, errors.New("empty")
+		}
+		return registered{value: str}, nil
+	})
+	RegisterDecoder(reflect.TypeOf(&registeredPointer{}), func(str string) (interface{}, error) {
+		return &registeredPointer{value: str}, nil
+	})
+	RegisterDecoder(reflect.TypeOf(convertible(0)), func(str string) (interface{}, error) {
+		return strconv.Atoi(str)
+	})
+	RegisterDecoder(reflect.TypeOf(unassignable(0)), func(str string) (interface{}, error) {
+		return str, nil
+	})
+
+	value, err := InitializeNewValueOfTypeWithString(reflect.TypeOf(registered{}), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, registered{value: "hello"}, value.Interface())
+
+	value, err = InitializeNewValueOfTypeWithString(reflect.TypeOf(&registered{}), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, &registered{value: "hello"}, value.Interface())
+
+	value, err = InitializeNewValueOfTypeWithJSON(reflect.TypeOf(&registeredPointer{}), []byte(`"hello"`))
+	require.NoError(t, err)
+	assert.Equal(t, &registeredPointer{value: "hello"}, value.Interface())
+
+	value, err = InitializeNewValueOfTypeWithString(reflect.TypeOf(convertible(0)), "42")
+	require.NoError(t, err)
+	assert.Equal(t, convertible(42), value.Interface())
+
+	// json values that are not strings are not given to decoders
+	value, err = InitializeNewValueOfTypeWithJSON(reflect.TypeOf(convertible(0)), []byte("42"))
+	require.NoError(t, err)
+	assert.Equal(t, convertible(42), value.Interface())
+
+	_, err = InitializeNewValueOfTypeWithString(reflect.TypeOf(registered{}), "")
+	require.Error(t, err)
+	_, err = InitializeNewValueOfTypeWithString(reflect.TypeOf(&registered{}), "")
+	require.Error(t, err)
+	_, err = InitializeNewValueOfTypeWithString(reflect.TypeOf(unassignable(0)), "hello")
+	require.Error(t, err)
+}
+
+type textLevel int
+
+func (l *textLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 1
+	case "info":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+	return nil
+}
+
+type flagList []string
+
+func (l *flagList) String() string { return strings.Join(*l, ",") }
+
+func (l *flagList) Set(str string) error {
+	*l = strings.Split(str, ",")
+	return nil
+}
+
+func Test_decodeWithTextUnmarshaler(t *testing.T) {
+	var tests = map[string]struct {
+		valueRepr       string
+		valueType       reflect.Type
+		expectedValue   interface{}
+		expectedFailure bool
+	}{
+		"text unmarshaler": {
+			valueRepr:     "info",
+			valueType:     reflect.TypeOf(textLevel(0)),
+			expectedValue: textLevel(2),
+		},
+		"text unmarshaler pointer": {
+			valueRepr:     "debug",
+			valueType:     reflect.TypeOf(new(textLevel)),
+			expectedValue: textLevel(1),
+		},
+		"text unmarshaler failure": {
+			valueRepr:       "nope",
+			valueType:       reflect.TypeOf(textLevel(0)),
+			expectedFailure: true,
+		},
+		"flag value": {
+			valueRepr:     "a,b",
+			valueType:     reflect.TypeOf(flagList{}),
+			expectedValue: flagList{"a", "b"},
+		},
+	}
+
+	for name, test := range tests {
+		var test = test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := InitializeNewValueOfTypeWithString(test.valueType, test.valueRepr)
+			if test.expectedFailure {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.valueType, value.Type())
+				assert.Equal(t, test.expectedValue, reflect.Indirect(*value).Interface())
+			}
+		})
+	}
+
+	_, exists, err := decodeWithTextUnmarshaler(reflect.TypeOf(0), "42")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func Test_HasStringDecoder(t *testing.T) {
+	assert.True(t, HasStringDecoder(reflect.TypeOf(url.URL{})))
+	assert.True(t, HasStringDecoder(reflect.TypeOf(&url.URL{})))
+	assert.True(t, HasStringDecoder(reflect.TypeOf(&regexp.Regexp{})))
+	assert.True(t, HasStringDecoder(reflect.TypeOf(textLevel(0))))
+	assert.True(t, HasStringDecoder(reflect.TypeOf(flagList{})))
+	assert.False(t, HasStringDecoder(reflect.TypeOf([]string{})))
+	assert.False(t, HasStringDecoder(reflect.TypeOf(0)))
+}
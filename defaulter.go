@@ -2,6 +2,7 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 )
 
@@ -14,6 +15,10 @@ type setDefaultFunc interface {
 // the `setDefaultFunc` interface, or to contain any fields (in
 // case of a struct) that implement it. The SetDefault method will
 // be called only if the value is the zero value.
+// Struct fields can also have a `default` tag whose value is parsed
+// like any string value (see InitializeNewValueOfTypeWithString),
+// and used if the field is still the zero value once SetDefault
+// methods have been called.
 func SetDefault(i interface{}) error {
 	var value = reflect.ValueOf(i)
 
@@ -26,12 +31,10 @@ func SetDefault(i interface{}) error {
 	value = reflect.Indirect(value.Elem())
 
 	// recursively walk through it to try to set default
-	walkThrough(&value)
-
-	return nil
+	return walkThrough(&value, "")
 }
 
-func walkThrough(v *reflect.Value) {
+func walkThrough(v *reflect.Value, name string) error {
 	switch v.Kind() {
 	case reflect.Ptr:
 		// we don't want to put default on nil pointed value so just leave here
@@ -43,7 +46,7 @@ func walkThrough(v *reflect.Value) {
 		pv := v.Elem()
 
 		// try to put a default recursively to the pointed value
-		walkThrough(&pv)
+		return walkThrough(&pv, name)
 	case reflect.Struct:
 		const tagKey = "default"
 		// try to put default on the whole structure
@@ -56,7 +59,8 @@ func walkThrough(v *reflect.Value) {
 			childField := v.Type().Field(i)
 
 			// check if the tag discard the field
-			if tag := childField.Tag.Get(tagKey); tag == "-" {
+			var tag = childField.Tag.Get(tagKey)
+			if tag == "-" {
 				continue
 			}
 
@@ -65,13 +69,50 @@ func walkThrough(v *reflect.Value) {
 				continue
 			}
 
+			// fields outside of the config tree still get defaults, named after the field
+			childName, ok := configTreeFieldPath(name, childField)
+			if !ok {
+				childName = appendConfigTreePath(name, childField.Name)
+			}
+
 			// handle the child recursively
-			walkThrough(&childV)
+			if err := walkThrough(&childV, childName); err != nil {
+				return err
+			}
+
+			// if the field still has no value, try with the tag
+			if tag != "" && isZeroValue(&childV) {
+				if err := setDefaultFromTag(&childV, childField, tag); err != nil {
+					return fmt.Errorf("unable to set default of field %s: %w", childName, err)
+				}
+			}
 		}
 	default:
 		// for every other types, try to set default
 		tryToSetDefault(v)
 	}
+
+	return nil
+}
+
+func setDefaultFromTag(v *reflect.Value, field reflect.StructField, tag string) error {
+	var (
+		newV *reflect.Value
+		err  error
+	)
+
+	if IsDelimitedType(v.Type()) {
+		sep, kvsep := DelimitersOfStructField(field)
+		newV, err = InitializeNewValueOfTypeWithDelimitedString(v.Type(), tag, sep, kvsep)
+	} else {
+		newV, err = InitializeNewValueOfTypeWithString(v.Type(), tag)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = SetNewValue(v, newV)
+	return err
 }
 
 func tryToSetDefault(v *reflect.Value) {
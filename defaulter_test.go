@@ -3,6 +3,7 @@ package config
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -49,6 +50,65 @@ func Test_SetDefault(t *testing.T) {
 	assert.Error(t, SetDefault(nil))
 }
 
+func Test_SetDefault_tag(t *testing.T) {
+	type nested struct {
+		Port    int           `default:"8080"`
+		Timeout time.Duration `default:"3s"`
+	}
+
+	type withTags struct {
+		Nested       nested
+		Pointer      *int              `default:"42"`
+		Method       stringDefaultable `default:"tag"`
+		AlreadySet   string            `default:"tag"`
+		Discard      stringDefaultable `default:"-"`
+		NestedSetter structSimple      `default:"{\"String\": \"tag\"}"`
+		Hosts        []string          `default:"a,b"`
+		Ports        [2]int            `default:"80;443" sep:";"`
+		Labels       map[string]string `default:"env:prod,team:core" kvsep:":"`
+	}
+
+	var (
+		answer = 42
+		cfg    = withTags{AlreadySet: "set"}
+	)
+
+	require.NoError(t, SetDefault(&cfg))
+	assert.Equal(t, withTags{
+		Nested: nested{
+			Port:    8080,
+			Timeout: 3 * time.Second,
+		},
+		Pointer:      &answer,
+		Method:       "world",
+		AlreadySet:   "set",
+		NestedSetter: structSimple{Different: "people"},
+		Hosts:        []string{"a", "b"},
+		Ports:        [2]int{80, 443},
+		Labels:       map[string]string{"env": "prod", "team": "core"},
+	}, cfg)
+
+	var invalid struct {
+		Nested struct {
+			Port int `default:"eighty"`
+		}
+	}
+
+	err := SetDefault(&invalid)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field nested.port")
+
+	var renamed struct {
+		Nested struct {
+			Port int `cfg:"listen_port" default:"eighty"`
+		} `cfg:"http"`
+	}
+
+	err = SetDefault(&renamed)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field http.listen_port")
+}
+
 func Test_tryToSetDefault(t *testing.T) {
 	var (
 		zeroString                  = ""
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// default separator between elements of slices, arrays and maps,
+	// can be overridden with the `sep` tag.
+	defaultSeparator = ","
+	// default separator between keys and values of maps,
+	// can be overridden with the `kvsep` tag.
+	defaultKeyValueSeparator = "="
+)
+
+// DelimitersOfStructField returns the separators to use for the delimited value
+// of a field: the `sep` and `kvsep` tags if defined, the defaults otherwise.
+func DelimitersOfStructField(field reflect.StructField) (sep, kvsep string) {
+	sep, kvsep = defaultSeparator, defaultKeyValueSeparator
+
+	if tag := field.Tag.Get("sep"); tag != "" {
+		sep = tag
+	}
+	if tag := field.Tag.Get("kvsep"); tag != "" {
+		kvsep = tag
+	}
+
+	return sep, kvsep
+}
+
+// IsDelimitedType returns true if values of this type can be read from
+// separator-delimited strings: slices, arrays and maps that does not know
+// how to decode themselves, bytes slices aside as they are not lists.
+func IsDelimitedType(typ reflect.Type) bool {
+	if HasStringDecoder(typ) {
+		return false
+	}
+
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		return typ.Elem().Kind() != reflect.Uint8
+	case reflect.Map:
+		return true
+	default:
+		return false
+	}
+}
+
+// InitializeNewValueOfTypeWithDelimitedString creates a new value of a slice, an array or a map type
+// from a string where elements are delimited by sep, and keys from values by kvsep. Separators can
+// be escaped with a backslash. For compatibility purposes, valid json lists and objects are also
+// handled; other values, even starting with a bracket like [::1]:80,[::2]:80, are delimited.
+// Decoding failures are returned as *DecodeError.
+func InitializeNewValueOfTypeWithDelimitedString(typ reflect.Type, str, sep, kvsep string) (*reflect.Value, error) {
+	var (
+		elemTyp = typ
+		trimmed = strings.TrimSpace(str)
+	)
+
+	if typ.Kind() == reflect.Ptr {
+		elemTyp = typ.Elem()
+	}
+
+	if (strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{")) && json.Valid([]byte(trimmed)) {
+		return InitializeNewValueOfTypeWithJSON(typ, []byte(str))
+	}
+
+	var (
+		v   = reflect.New(elemTyp).Elem()
+		err error
+	)
+
+	switch elemTyp.Kind() {
+	case reflect.Map:
+		err = setMapWithDelimitedString(&v, str, sep, kvsep)
+	default:
+		err = setListWithDelimitedString(&v, str, sep)
+	}
+
+	if err != nil {
+		return nil, &DecodeError{Type: typ, RawValue: str, Err: err}
+	}
+
+	if typ.Kind() == reflect.Ptr {
+		ptr := reflect.New(elemTyp)
+		ptr.Elem().Set(v)
+		return &ptr, nil
+	}
+
+	return &v, nil
+}
+
+func setListWithDelimitedString(v *reflect.Value, str, sep string) error {
+	var parts []string
+	if str != "" {
+		parts = splitEscaped(str, sep, -1, true)
+	}
+
+	if v.Kind() == reflect.Array {
+		if len(parts) > v.Len() {
+			return fmt.Errorf("%d elements given but array can only contain %d", len(parts), v.Len())
+		}
+	} else {
+		v.Set(reflect.MakeSlice(v.Type(), len(parts), len(parts)))
+	}
+
+	for i, part := range parts {
+		elem, err := InitializeNewValueOfTypeWithString(v.Type().Elem(), part)
+		if err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+		v.Index(i).Set(*elem)
+	}
+
+	return nil
+}
+
+func setMapWithDelimitedString(v *reflect.Value, str, sep, kvsep string) error {
+	v.Set(reflect.MakeMap(v.Type()))
+
+	if str == "" {
+		return nil
+	}
+
+	for _, pair := range splitEscaped(str, sep, -1, false) {
+		kv := splitEscaped(pair, kvsep, 2, true)
+		if len(kv) != 2 {
+			return fmt.Errorf("%q is not a key%svalue pair", pair, kvsep)
+		}
+
+		key, err := InitializeNewValueOfTypeWithString(v.Type().Key(), kv[0])
+		if err != nil {
+			return fmt.Errorf("key %q: %w", kv[0], err)
+		}
+
+		value, err := InitializeNewValueOfTypeWithString(v.Type().Elem(), kv[1])
+		if err != nil {
+			return fmt.Errorf("value of key %q: %w", kv[0], err)
+		}
+
+		v.SetMapIndex(*key, *value)
+	}
+
+	return nil
+}
+
+// splitEscaped splits str around each sep that is not escaped with a backslash,
+// in at most n parts if n is positive. Escaping backslashes are removed if unescape
+// is true, and kept otherwise.
+func splitEscaped(str, sep string, n int, unescape bool) []string {
+	var (
+		parts []string
+		part  strings.Builder
+	)
+
+	for i := 0; i < len(str); i++ {
+		switch {
+		case str[i] == '\\' && i+1 < len(str):
+			if !unescape {
+				part.WriteByte(str[i])
+			}
+			i++
+			part.WriteByte(str[i])
+		case strings.HasPrefix(str[i:], sep) && (n <= 0 || len(parts) < n-1):
+			parts = append(parts, part.String())
+			part.Reset()
+			i += len(sep) - 1
+		default:
+			part.WriteByte(str[i])
+		}
+	}
+
+	return append(parts, part.String())
+}
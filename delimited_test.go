@@ -0,0 +1,190 @@
+package config
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_IsDelimitedType(t *testing.T) {
+	assert.True(t, IsDelimitedType(reflect.TypeOf([]string{})))
+	assert.True(t, IsDelimitedType(reflect.TypeOf(&[]int{})))
+	assert.True(t, IsDelimitedType(reflect.TypeOf([2]int{})))
+	assert.True(t, IsDelimitedType(reflect.TypeOf(map[string]int{})))
+	assert.False(t, IsDelimitedType(reflect.TypeOf([]byte{})))
+	assert.False(t, IsDelimitedType(reflect.TypeOf(net.IP{})))
+	assert.False(t, IsDelimitedType(reflect.TypeOf("")))
+}
+
+func Test_InitializeNewValueOfTypeWithDelimitedString(t *testing.T) {
+	var tests = map[string]struct {
+		valueRepr       string
+		valueType       reflect.Type
+		sep             string
+		kvsep           string
+		expectedValue   interface{}
+		expectedFailure bool
+	}{
+		"slice of strings": {
+			valueRepr:     "a,b,c",
+			valueType:     reflect.TypeOf([]string{}),
+			expectedValue: []string{"a", "b", "c"},
+		},
+		"slice with escaped separator": {
+			valueRepr:     `a\,b,c\\`,
+			valueType:     reflect.TypeOf([]string{}),
+			expectedValue: []string{"a,b", `c\`},
+		},
+		"slice with custom separator": {
+			valueRepr:     "a,b;c",
+			valueType:     reflect.TypeOf([]string{}),
+			sep:           ";",
+			expectedValue: []string{"a,b", "c"},
+		},
+		"empty slice": {
+			valueRepr:     "",
+			valueType:     reflect.TypeOf([]string{}),
+			expectedValue: []string{},
+		},
+		"slice of durations": {
+			valueRepr:     "1s,2m",
+			valueType:     reflect.TypeOf([]time.Duration{}),
+			expectedValue: []time.Duration{time.Second, 2 * time.Minute},
+		},
+		"slice of invalid ints": {
+			valueRepr:       "1,two",
+			valueType:       reflect.TypeOf([]int{}),
+			expectedFailure: true,
+		},
+		"pointer on slice": {
+			valueRepr:     "1,2",
+			valueType:     reflect.TypeOf(&[]int{}),
+			expectedValue: []int{1, 2},
+		},
+		"json slice": {
+			valueRepr:     `["a,b", "c"]`,
+			valueType:     reflect.TypeOf([]string{}),
+			expectedValue: []string{"a,b", "c"},
+		},
+		"invalid json slice": {
+			valueRepr:       `["a", 1]`,
+			valueType:       reflect.TypeOf([]string{}),
+			expectedFailure: true,
+		},
+		"delimited values starting with a bracket": {
+			valueRepr:     "[::1]:80,[::2]:80",
+			valueType:     reflect.TypeOf([]string{}),
+			expectedValue: []string{"[::1]:80", "[::2]:80"},
+		},
+		"delimited map starting with a brace": {
+			valueRepr:     "{a}=1,b=2",
+			valueType:     reflect.TypeOf(map[string]int{}),
+			expectedValue: map[string]int{"{a}": 1, "b": 2},
+		},
+		"array": {
+			valueRepr:     "1,2",
+			valueType:     reflect.TypeOf([3]int{}),
+			expectedValue: [3]int{1, 2, 0},
+		},
+		"array too small": {
+			valueRepr:       "1,2,3,4",
+			valueType:       reflect.TypeOf([3]int{}),
+			expectedFailure: true,
+		},
+		"map": {
+			valueRepr:     "a=1,b=2",
+			valueType:     reflect.TypeOf(map[string]int{}),
+			expectedValue: map[string]int{"a": 1, "b": 2},
+		},
+		"map with escaped separators": {
+			valueRepr:     `a\=b=c\,d=e,f=`,
+			valueType:     reflect.TypeOf(map[string]string{}),
+			expectedValue: map[string]string{"a=b": "c,d=e", "f": ""},
+		},
+		"map with custom separators": {
+			valueRepr:     "a:1;b:2",
+			valueType:     reflect.TypeOf(map[string]int{}),
+			sep:           ";",
+			kvsep:         ":",
+			expectedValue: map[string]int{"a": 1, "b": 2},
+		},
+		"empty map": {
+			valueRepr:     "",
+			valueType:     reflect.TypeOf(map[string]int{}),
+			expectedValue: map[string]int{},
+		},
+		"json map": {
+			valueRepr:     `{"a": 1}`,
+			valueType:     reflect.TypeOf(map[string]int{}),
+			expectedValue: map[string]int{"a": 1},
+		},
+		"map without key value separator": {
+			valueRepr:       "a,b",
+			valueType:       reflect.TypeOf(map[string]string{}),
+			expectedFailure: true,
+		},
+		"map with invalid key": {
+			valueRepr:       "a=1",
+			valueType:       reflect.TypeOf(map[int]int{}),
+			expectedFailure: true,
+		},
+		"map with invalid value": {
+			valueRepr:       "1=a",
+			valueType:       reflect.TypeOf(map[int]int{}),
+			expectedFailure: true,
+		},
+	}
+
+	for name, test := range tests {
+		var test = test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if test.sep == "" {
+				test.sep = defaultSeparator
+			}
+			if test.kvsep == "" {
+				test.kvsep = defaultKeyValueSeparator
+			}
+
+			value, err := InitializeNewValueOfTypeWithDelimitedString(
+				test.valueType, test.valueRepr, test.sep, test.kvsep,
+			)
+			if test.expectedFailure {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.valueType, value.Type())
+				assert.Equal(t, test.expectedValue, reflect.Indirect(*value).Interface())
+			}
+		})
+	}
+}
+
+func Test_DelimitersOfStructField(t *testing.T) {
+	var cfg struct {
+		Default []string
+		Custom  map[string]string `sep:";" kvsep:":"`
+	}
+
+	sep, kvsep := DelimitersOfStructField(reflect.TypeOf(cfg).Field(0))
+	assert.Equal(t, ",", sep)
+	assert.Equal(t, "=", kvsep)
+
+	sep, kvsep = DelimitersOfStructField(reflect.TypeOf(cfg).Field(1))
+	assert.Equal(t, ";", sep)
+	assert.Equal(t, ":", kvsep)
+}
+
+func Test_splitEscaped(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "c"}, splitEscaped("a::b::c", "::", -1, true))
+	assert.Equal(t, []string{"a", "b::c"}, splitEscaped("a::b::c", "::", 2, true))
+	assert.Equal(t, []string{`a\,b`, "c"}, splitEscaped(`a\,b,c`, ",", -1, false))
+	assert.Equal(t, []string{"a,b", "c"}, splitEscaped(`a\,b,c`, ",", -1, true))
+	assert.Equal(t, []string{`a\`}, splitEscaped(`a\`, ",", -1, true))
+	assert.Equal(t, []string{""}, splitEscaped("", ",", -1, true))
+}
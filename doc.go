@@ -12,7 +12,90 @@ Configuration loading is as easy as:
 		panic(err)
 	}
 
-See each sources to get more details on how to use them.
+See each sources to get more details on how to use them. Sources are applied
+in order, so the last one takes precedence; the map source is handy to apply
+programmatic overrides on top of others:
+
+	mapsource.New(map[string]interface{}{"http.listenaddress": ":9000"})
+
+Custom sources implement Source and one of the load interfaces (SourceUnmarshal,
+SourceSetValueFromConfigTreePath, or their context-aware variants). Missing keys
+are reported with trivial errors, like trivialerr.ErrNotFound, that the loader ignores.
+
+Sources that handle values as strings (like the env source) decode them through
+InitializeNewValueOfTypeWithString, which handles any type the json package handles,
+types that implement encoding.TextUnmarshaler or flag.Value, time.Duration, url.URL,
+net.IP, net.IPNet, *regexp.Regexp, time.Time, *time.Location, os.FileMode and big.Int.
+Other types can be handled by registering a decoder:
+
+	config.RegisterDecoder(reflect.TypeOf(Level(0)), func(str string) (interface{}, error) {
+		return ParseLevel(str)
+	})
+
+To know where each values came from, use LoadWithReport instead:
+
+	report, err := config.LoadWithReport(&cfg, config.WithSources(
+		sourcefile.New("./config.yaml"),
+		sourceenv.New("myapp"),
+	))
+	// report["http.listenaddress"] is either "default", "unset",
+	// or the name of the last source that set the value
+
+Config tree paths
+
+Sources and the loader identify values by config tree paths: the lowercased
+names of the fields leading to the value (or the name in the `cfg` tag), joined
+by dots, like "http.listenaddress". Indexes and map keys are also path parts,
+like "servers.0.host". Get and Set read and write values by path:
+
+	addr, err := config.Get(&cfg, "http.listenaddress")
+	err = config.Set(&cfg, "http.listenaddress", ":9000")
+
+Lifecycle hooks
+
+Like defaults and validation, the loader walks recursively through the
+configuration to call two optional methods:
+BeforeLoad() error, once defaults are set, on parents before their fields,
+and AfterLoad() error, once all sources are loaded, on fields before their parents.
+AfterLoad is the right place to derive fields from loaded ones:
+
+	type Database struct {
+		DSN  string
+		Host string `cfg:"-"`
+	}
+
+	func (d *Database) AfterLoad() error {
+		u, err := url.Parse(d.DSN)
+		if err != nil {
+			return err
+		}
+		d.Host = u.Host
+		return nil
+	}
+
+Required fields
+
+A field can be marked as required with the `cfg:",required"` tag (or
+`cfg:"name,required"` to also rename it). Once all sources have been loaded,
+a RequiredFieldsError listing every required field set neither by defaults
+nor by any sources is returned, along with the keys sources looked up:
+
+	type DatabaseConfig struct {
+		Password string `cfg:",required"`
+	}
+	// missing required fields: password (env: MYAPP_PASSWORD, file: ./config.yaml (tree path password))
+
+Watch
+
+Long-running programs can reload their configuration each time a source
+that implements SourceWatcher notifies a change:
+
+	store, err := config.NewStore(&cfg)
+	// ...
+	go loader.Watch(ctx, store, func(newCfg interface{}, err error) {
+		// called after each reload, newCfg is valid if err is nil
+	})
+	// store.Get().(*Config) always returns the last valid configuration
 
 Defaults
 
@@ -55,6 +138,19 @@ If we want to apply defaults to an instance of furniture, we just have to:
 	defaulter.SetDefault(&f)
 	// f has an unknown color and is available
 
+Applying defaults through tags
+
+For simple values, the `default` tag can be used instead of a method:
+
+	type HTTPConfig struct {
+		ListenAddress string        `default:":8080"`
+		Timeout       time.Duration `default:"3s"`
+	}
+
+The tag value is parsed like values coming from the environment, and
+is only used if the field is still the zero value once SetDefault methods
+have been called, so methods take precedence over tags.
+
 Special cases when using defaults
 
 We already see that the SetDefault method will be call
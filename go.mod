@@ -3,6 +3,7 @@ module github.com/krostar/config
 go 1.14
 
 require (
+	github.com/BurntSushi/toml v0.3.1
 	github.com/kr/pretty v0.1.0 // indirect
 	github.com/spf13/afero v1.2.2
 	github.com/stretchr/objx v0.2.0 // indirect
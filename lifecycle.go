@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+type beforeLoadFunc interface {
+	BeforeLoad() error
+}
+
+type afterLoadFunc interface {
+	AfterLoad() error
+}
+
+// callBeforeLoad calls beforeLoadFunc for all types that implements it.
+// Parents are called before their fields.
+func callBeforeLoad(i interface{}) error {
+	return callLifecycleHook(i, true, func(v interface{}) error {
+		if f, ok := v.(beforeLoadFunc); ok {
+			return f.BeforeLoad()
+		}
+		return nil
+	})
+}
+
+// callAfterLoad calls afterLoadFunc for all types that implements it.
+// Fields are called before their parents, so parents can use what their
+// fields derived.
+func callAfterLoad(i interface{}) error {
+	return callLifecycleHook(i, false, func(v interface{}) error {
+		if f, ok := v.(afterLoadFunc); ok {
+			return f.AfterLoad()
+		}
+		return nil
+	})
+}
+
+func callLifecycleHook(i interface{}, parentFirst bool, hook func(v interface{}) error) error {
+	var value = reflect.ValueOf(i)
+
+	// if the reflected value is nil
+	if !value.IsValid() {
+		return errors.New("i value is nil")
+	}
+
+	// as i is actually an interface, just get the thing behind the interface
+	value = reflect.Indirect(value.Elem())
+
+	return callLifecycleHookRecursively(&value, "", parentFirst, hook)
+}
+
+func callLifecycleHookRecursively(
+	v *reflect.Value, name string, parentFirst bool, hook func(v interface{}) error,
+) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		// there is nothing to call on nil pointed value so just leave here
+		if v.IsNil() {
+			break
+		}
+
+		// retrieve the pointed value
+		var pv = v.Elem()
+
+		// call the hook recursively on the pointed value
+		return callLifecycleHookRecursively(&pv, name, parentFirst, hook)
+	case reflect.Struct:
+		if parentFirst {
+			if err := callLifecycleHookOnValue(v, name, hook); err != nil {
+				return err
+			}
+		}
+
+		// try each fields, they may also implement the hook
+		for i := 0; i < v.NumField(); i++ {
+			var childV = v.Field(i)
+
+			// ignore it if the field is not part of the configuration
+			childName, ok := configTreeFieldPath(name, v.Type().Field(i))
+			if !ok {
+				continue
+			}
+
+			// handle the child recursively
+			if err := callLifecycleHookRecursively(&childV, childName, parentFirst, hook); err != nil {
+				return err
+			}
+		}
+
+		if !parentFirst {
+			return callLifecycleHookOnValue(v, name, hook)
+		}
+	default:
+		// for every other types, try to call the hook
+		return callLifecycleHookOnValue(v, name, hook)
+	}
+
+	return nil
+}
+
+func callLifecycleHookOnValue(v *reflect.Value, name string, hook func(v interface{}) error) error {
+	// since hooks may update the value, they need to receive a pointor
+	if !v.IsValid() || !v.CanInterface() || !v.CanAddr() {
+		return nil
+	}
+
+	if err := hook(v.Addr().Interface()); err != nil {
+		if name == "" {
+			return err
+		}
+		return fmt.Errorf("field %s: %w", name, err)
+	}
+
+	return nil
+}
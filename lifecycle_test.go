@@ -0,0 +1,88 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type lifecycleCalls []string
+
+type lifecycleDSN struct {
+	calls *lifecycleCalls
+	DSN   string
+	Host  string `cfg:"-"`
+}
+
+func (d *lifecycleDSN) BeforeLoad() error {
+	*d.calls = append(*d.calls, "before dsn")
+	return nil
+}
+
+func (d *lifecycleDSN) AfterLoad() error {
+	*d.calls = append(*d.calls, "after dsn")
+	if d.DSN == "" {
+		return errors.New("dsn is empty")
+	}
+	d.Host = "host of " + d.DSN
+	return nil
+}
+
+type lifecycleCfg struct {
+	calls    *lifecycleCalls
+	Database lifecycleDSN
+	Nil      *lifecycleDSN
+	Address  string
+}
+
+func (c *lifecycleCfg) BeforeLoad() error {
+	*c.calls = append(*c.calls, "before cfg")
+	return nil
+}
+
+func (c *lifecycleCfg) AfterLoad() error {
+	*c.calls = append(*c.calls, "after cfg")
+	c.Address = c.Database.Host
+	return nil
+}
+
+func newLifecycleCfg() *lifecycleCfg {
+	var calls = new(lifecycleCalls)
+	return &lifecycleCfg{calls: calls, Database: lifecycleDSN{calls: calls}}
+}
+
+func Test_Load_lifecycle_hooks(t *testing.T) {
+	var cfg = newLifecycleCfg()
+
+	require.NoError(t, Load(cfg, WithRawSources(stubSourceThatUseReflection{
+		"database.dsn": "dsn",
+	})))
+	assert.Equal(t, "host of dsn", cfg.Database.Host)
+	assert.Equal(t, "host of dsn", cfg.Address)
+	assert.Equal(t, lifecycleCalls{"before cfg", "before dsn", "after dsn", "after cfg"}, *cfg.calls)
+
+	cfg = newLifecycleCfg()
+	err := Load(cfg)
+	require.Error(t, err)
+	assert.Equal(t, "after load hook failed: field database: dsn is empty", err.Error())
+	assert.Equal(t, lifecycleCalls{"before cfg", "before dsn", "after dsn"}, *cfg.calls)
+
+	// after load hooks are not called if the load failed
+	cfg = newLifecycleCfg()
+	require.Error(t, Load(cfg, WithRawSources(stubSourceThatUnmarshal(1))))
+	assert.Equal(t, lifecycleCalls{"before cfg", "before dsn"}, *cfg.calls)
+}
+
+func Test_callLifecycleHook(t *testing.T) {
+	require.Error(t, callBeforeLoad(nil))
+	require.Error(t, callAfterLoad(nil))
+
+	var str = "hello"
+	require.NoError(t, callAfterLoad(&str))
+
+	err := callLifecycleHook(newLifecycleCfg(), true, func(interface{}) error { return errors.New("boum") })
+	require.Error(t, err)
+	assert.Equal(t, "boum", err.Error())
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// LoadError describes a failure that happened during the load of the configuration.
+type LoadError struct {
+	// Source is the name of the source that failed, if any.
+	Source string
+	// TreePath is the config tree path that failed to be loaded, if any.
+	TreePath string
+	// RawValue is the value that failed to be decoded, if any.
+	RawValue string
+	// Err is the reason of the failure.
+	Err error
+}
+
+func newLoadError(source string, treePath string, err error) *LoadError {
+	var loadErr = LoadError{
+		Source:   source,
+		TreePath: treePath,
+		Err:      err,
+	}
+
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		loadErr.RawValue = decodeErr.RawValue
+	}
+
+	return &loadErr
+}
+
+// Error implements error.
+func (e *LoadError) Error() string {
+	var msg []string
+
+	if e.Source != "" {
+		msg = append(msg, "source "+e.Source)
+	}
+	if e.TreePath != "" {
+		msg = append(msg, fmt.Sprintf("key %q", e.TreePath))
+	}
+
+	msg = append(msg, e.Err.Error())
+
+	return strings.Join(msg, ": ")
+}
+
+// Unwrap implements errors.Unwrap.
+func (e *LoadError) Unwrap() error { return e.Err }
+
+// LoadErrors contains all failures that happened during the load
+// of the configuration, when loaded with WithErrorsAggregation.
+type LoadErrors []*LoadError
+
+// Error implements error.
+func (e LoadErrors) Error() string {
+	var errs = make([]string, len(e))
+	for i, err := range e {
+		errs[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d errors occurred: %s", len(e), strings.Join(errs, ", "))
+}
+
+// Is returns true if any of the errors matches target (see errors.Is).
+func (e LoadErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors that matches target (see errors.As).
+func (e LoadErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unwrap returns all errors. Is and As already check each of
+// them, this is for callers that want to walk through them.
+func (e LoadErrors) Unwrap() []error {
+	var errs = make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
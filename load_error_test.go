@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Load_errors_aggregation(t *testing.T) {
+	type icfg struct {
+		First    int
+		Second   int
+		Valid    string
+		Required string `cfg:",required"`
+	}
+
+	var (
+		cfg    icfg
+		source = stubSourceThatUseReflection{
+			"first":  "one",
+			"second": "two",
+			"valid":  "valid",
+		}
+	)
+
+	// without aggregation, the load stops at the first failure
+	err := Load(&cfg, WithRawSources(stubSourceThatUnmarshal(1), source))
+	require.Error(t, err)
+	assert.False(t, errors.As(err, new(LoadErrors)))
+
+	err = Load(&cfg, WithRawSources(stubSourceThatUnmarshal(1), source), WithErrorsAggregation())
+	require.Error(t, err)
+	assert.Equal(t, "valid", cfg.Valid)
+
+	var errs LoadErrors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 4)
+
+	assert.Equal(t, "stub unmarshal", errs[0].Source)
+	assert.Empty(t, errs[0].TreePath)
+	assert.Empty(t, errs[0].RawValue)
+
+	assert.Equal(t, "stub reflect", errs[1].Source)
+	assert.Equal(t, "first", errs[1].TreePath)
+	assert.Equal(t, "one", errs[1].RawValue)
+
+	assert.Equal(t, "stub reflect", errs[2].Source)
+	assert.Equal(t, "second", errs[2].TreePath)
+	assert.Equal(t, "two", errs[2].RawValue)
+
+	var requiredErr RequiredFieldsError
+	assert.True(t, errors.As(errs[3], &requiredErr))
+
+	// each failure can be retrieved through errors.As
+	var (
+		loadErr   *LoadError
+		decodeErr *DecodeError
+	)
+	require.True(t, errors.As(err, &loadErr))
+	assert.Equal(t, errs[0], loadErr)
+	require.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, "one", decodeErr.RawValue)
+	require.True(t, errors.As(err, &requiredErr))
+}
+
+func TestLoadError_Error(t *testing.T) {
+	var err = errors.New("boum")
+
+	assert.Equal(t, "boum", (&LoadError{Err: err}).Error())
+	assert.Equal(t, "source env: boum", (&LoadError{Source: "env", Err: err}).Error())
+	assert.Equal(t, `source env: key "a.b": boum`, (&LoadError{Source: "env", TreePath: "a.b", Err: err}).Error())
+	assert.Equal(t, err, (&LoadError{Err: err}).Unwrap())
+}
+
+func TestLoadErrors_Error(t *testing.T) {
+	var errs = LoadErrors{
+		{Source: "s1", Err: errors.New("boum")},
+		{Source: "s2", Err: errors.New("bam")},
+	}
+
+	assert.Equal(t, "2 errors occurred: source s1: boum, source s2: bam", errs.Error())
+	assert.Equal(t, []error{errs[0], errs[1]}, errs.Unwrap())
+}
+
+func TestLoadErrors_Is_As(t *testing.T) {
+	var (
+		sentinel  = errors.New("sentinel")
+		decodeErr = &DecodeError{RawValue: "raw", Err: sentinel}
+		errs      = LoadErrors{
+			{Source: "s1", Err: errors.New("boum")},
+			{Source: "s2", Err: decodeErr},
+		}
+	)
+
+	// methods are called directly to not depend on errors.Unwrap() []error support
+	assert.True(t, errs.Is(sentinel))
+	assert.False(t, errs.Is(errors.New("sentinel")))
+
+	var target *DecodeError
+	assert.True(t, errs.As(&target))
+	assert.Equal(t, decodeErr, target)
+
+	var requiredErr RequiredFieldsError
+	assert.False(t, errs.As(&requiredErr))
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"reflect"
+)
+
+const (
+	// LoadReportDefault is the value of a LoadReport entry
+	// whose value has been set by defaults.
+	LoadReportDefault = "default"
+	// LoadReportUnset is the value of a LoadReport entry
+	// whose value has not been set by defaults nor by any sources.
+	LoadReportUnset = "unset"
+)
+
+// LoadReport tells where each values of the configuration came from.
+// Keys are config tree paths, and values the name of the last source
+// that set the value, LoadReportDefault or LoadReportUnset. Slices, arrays
+// and maps are reported as a whole, even if sources set their elements.
+//
+// Values set by a source that implements SourceUnmarshal are detected
+// by comparing the configuration before and after the call, which means
+// such a source that set a value to what it already was won't be reported.
+type LoadReport map[string]string
+
+// Source returns what set the value of the provided config tree path.
+func (r LoadReport) Source(treePath string) string {
+	if source, exists := r[treePath]; exists {
+		return source
+	}
+	return LoadReportUnset
+}
+
+// reportChanges sets source as the origin of each values that changed between
+// the before and after snapshots.
+func (r LoadReport) reportChanges(source string, before, after configTreeSnapshot) {
+	for _, treePath := range before.changedPaths(after) {
+		r[treePath] = source
+	}
+}
+
+// absentValue is used in snapshots for values behind nil pointers.
+type absentValue struct{}
+
+// configTreeSnapshot holds a copy of each leaf values of a configuration.
+type configTreeSnapshot map[string]interface{}
+
+// changedPaths returns the config tree paths whose values differ in the after snapshot.
+func (s configTreeSnapshot) changedPaths(after configTreeSnapshot) []string {
+	var paths []string
+
+	for treePath, value := range after {
+		if !reflect.DeepEqual(s[treePath], value) {
+			paths = append(paths, treePath)
+		}
+	}
+
+	return paths
+}
+
+func takeConfigTreeSnapshot(cfg interface{}) configTreeSnapshot {
+	var (
+		snapshot = make(configTreeSnapshot)
+		value    = reflect.ValueOf(cfg)
+	)
+
+	if value.IsValid() && value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = reflect.Indirect(value.Elem())
+		snapshot.takeRecursively("", value, true)
+	}
+
+	return snapshot
+}
+
+func (s configTreeSnapshot) takeRecursively(path string, v reflect.Value, present bool) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		s[path] = absentValue{}
+	case reflect.Ptr:
+		// pointers with a decoder registered for them are leaves
+		if _, exists := lookupDecoder(v.Type()); exists {
+			s.takeLeaf(path, v, present)
+			break
+		}
+
+		// we still want to know about values behind nil pointors, but they are absent
+		if v.IsNil() {
+			s.takeRecursively(path, reflect.Zero(v.Type().Elem()), false)
+			break
+		}
+
+		s.takeRecursively(path, v.Elem(), present)
+	case reflect.Struct:
+		// structs that know how to decode themselves are leaves
+		if HasStringDecoder(v.Type()) {
+			s.takeLeaf(path, v, present)
+			break
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			if childPath, ok := configTreeFieldPath(path, v.Type().Field(i)); ok {
+				s.takeRecursively(childPath, v.Field(i), present)
+			}
+		}
+	default:
+		s.takeLeaf(path, v, present)
+	}
+}
+
+func (s configTreeSnapshot) takeLeaf(path string, v reflect.Value, present bool) {
+	if !present || !v.CanInterface() {
+		s[path] = absentValue{}
+		return
+	}
+
+	// copy the value so future modifications of the configuration
+	// do not change the snapshot (maps and slices are references)
+	s[path] = deepCopyValue(v).Interface()
+}
+
+func deepCopyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, key := range v.MapKeys() {
+			c.SetMapIndex(key, deepCopyValue(v.MapIndex(key)))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return c
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopyValue(v.Elem()))
+		return c
+	case reflect.Struct:
+		// unexported fields are copied as is, as they can't be set one by one
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopyValue(v.Field(i)))
+			}
+		}
+		return c
+	default:
+		return v
+	}
+}
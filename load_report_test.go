@@ -0,0 +1,134 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reportCfgNested struct {
+	Hello string
+}
+
+func (c *reportCfgNested) SetDefault() { c.Hello = "world" }
+
+type reportCfg struct {
+	FromDefault  reportCfgNested
+	FromFirst    string
+	FromSecond   int
+	FromBoth     string `cfg:"both"`
+	Unset        []string
+	Discarded    string `cfg:"-"`
+	NilPointer   *reportCfgNested
+	unexported   string
+	SameAsBefore string
+}
+
+type stubSourceThatUnmarshalFunc func(cfg interface{}) error
+
+func (s stubSourceThatUnmarshalFunc) Name() string { return "stub unmarshal func" }
+
+func (s stubSourceThatUnmarshalFunc) Unmarshal(cfg interface{}) error { return s(cfg) }
+
+func Test_LoadWithReport(t *testing.T) {
+	var (
+		cfg = reportCfg{SameAsBefore: "same"}
+		s1  = stubSourceThatUnmarshalFunc(func(i interface{}) error {
+			c := i.(*reportCfg)
+			c.FromFirst = "first"
+			c.FromBoth = "first"
+			c.SameAsBefore = "same"
+			return nil
+		})
+		s2 = stubSourceThatUseReflection{
+			"fromsecond":   "42",
+			"both":         "second",
+			"sameasbefore": "same",
+		}
+	)
+
+	report, err := LoadWithReport(&cfg, WithRawSources(s1, s2))
+	require.NoError(t, err)
+	assert.Equal(t, LoadReport{
+		"fromdefault.hello": LoadReportDefault,
+		"fromfirst":         s1.Name(),
+		"fromsecond":        s2.Name(),
+		"both":              s2.Name(),
+		"unset":             LoadReportUnset,
+		"nilpointer.hello":  LoadReportUnset,
+		"sameasbefore":      s2.Name(),
+	}, report)
+
+	assert.Equal(t, s1.Name(), report.Source("fromfirst"))
+	assert.Equal(t, LoadReportUnset, report.Source("does.not.exists"))
+
+	_, err = LoadWithReport(nil)
+	require.Error(t, err)
+	_, err = LoadWithReport(&cfg, WithRawSources(stubSourceThatUnmarshal(1)))
+	require.Error(t, err)
+}
+
+func Test_LoadWithReport_collections(t *testing.T) {
+	type (
+		server struct {
+			Host string
+			Port int
+		}
+		collectionsCfg struct {
+			Servers []server
+			Ports   map[string]int
+			Hosts   []string
+		}
+	)
+
+	var (
+		cfg collectionsCfg
+		s1  = stubSourceThatUnmarshalFunc(func(i interface{}) error {
+			i.(*collectionsCfg).Hosts = []string{"a", "b"}
+			return nil
+		})
+		s2 = stubSourceThatListKeys{stubSourceThatUseReflection: stubSourceThatUseReflection{
+			"servers.0.host": "localhost",
+			"servers.1.port": "8080",
+			"ports.http":     "80",
+		}}
+	)
+
+	// elements set by tree path sources are reported like
+	// collections changed by sources that unmarshal
+	report, err := LoadWithReport(&cfg, WithRawSources(s1, s2))
+	require.NoError(t, err)
+	assert.Equal(t, collectionsCfg{
+		Servers: []server{{Host: "localhost"}, {Port: 8080}},
+		Ports:   map[string]int{"http": 80},
+		Hosts:   []string{"a", "b"},
+	}, cfg)
+	assert.Equal(t, LoadReport{
+		"servers": s2.Name(),
+		"ports":   s2.Name(),
+		"hosts":   s1.Name(),
+	}, report)
+}
+
+func Test_takeConfigTreeSnapshot(t *testing.T) {
+	var cfg = reportCfg{
+		Unset:      []string{"a"},
+		NilPointer: &reportCfgNested{Hello: "hello"},
+	}
+
+	snapshot := takeConfigTreeSnapshot(&cfg)
+	assert.Equal(t, []string{"a"}, snapshot["unset"])
+	assert.Equal(t, "hello", snapshot["nilpointer.hello"])
+	assert.NotContains(t, snapshot, "discarded")
+	assert.NotContains(t, snapshot, "unexported")
+
+	// modifying the config should not modify the snapshot
+	cfg.Unset[0] = "b"
+	assert.Equal(t, []string{"a"}, snapshot["unset"])
+
+	cfg.NilPointer = nil
+	assert.Equal(t, absentValue{}, takeConfigTreeSnapshot(&cfg)["nilpointer.hello"])
+
+	assert.Empty(t, takeConfigTreeSnapshot(nil))
+}
@@ -24,3 +24,12 @@ func WithSources(sf ...SourceCreationFunc) Option {
 		return nil
 	}
 }
+
+// WithErrorsAggregation makes the load keep going when a source fails to load, or
+// fails to set a value. All failures are then returned at once as LoadErrors.
+func WithErrorsAggregation() Option {
+	return func(c *Config) error {
+		c.aggregateErrors = true
+		return nil
+	}
+}
@@ -35,3 +35,10 @@ func Test_WithSources(t *testing.T) {
 	err = WithSources(s1, s2)(&c)
 	require.Error(t, err)
 }
+
+func Test_WithErrorsAggregation(t *testing.T) {
+	var c Config
+
+	require.NoError(t, WithErrorsAggregation()(&c))
+	assert.True(t, c.aggregateErrors)
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// configTreeFieldOptionRequired is the `cfg` tag option that marks a field as required.
+const configTreeFieldOptionRequired = "required"
+
+// RequiredFieldsError lists every required fields
+// that were set neither by defaults nor by any sources.
+type RequiredFieldsError []RequiredField
+
+// RequiredField describes a required field that has not been set.
+type RequiredField struct {
+	// TreePath is the config tree path of the field.
+	TreePath string
+	// SourceKeys contains the keys sources looked up to find the
+	// value, for sources that implement SourceKeyDescriber.
+	SourceKeys []SourceKey
+}
+
+// SourceKey is a key looked up by a source.
+type SourceKey struct {
+	Source string
+	Key    string
+}
+
+// String implements Stringer for RequiredField.
+func (f RequiredField) String() string {
+	if len(f.SourceKeys) == 0 {
+		return f.TreePath
+	}
+
+	var keys = make([]string, len(f.SourceKeys))
+	for i, key := range f.SourceKeys {
+		keys[i] = key.Source + ": " + key.Key
+	}
+
+	return fmt.Sprintf("%s (%s)", f.TreePath, strings.Join(keys, ", "))
+}
+
+// String implements Stringer for RequiredFieldsError.
+func (e RequiredFieldsError) String() string {
+	var fields = make([]string, len(e))
+	for i, field := range e {
+		fields[i] = field.String()
+	}
+
+	return "missing required fields: " + strings.Join(fields, ", ")
+}
+
+// Error implements error.
+func (e RequiredFieldsError) Error() string { return e.String() }
+
+// hasRequiredFields returns true if the type, or any of its
+// fields recursively, contains a required field.
+func hasRequiredFields(typ reflect.Type) bool {
+	return hasRequiredFieldsRecursively(typ, make(map[reflect.Type]bool))
+}
+
+func hasRequiredFieldsRecursively(typ reflect.Type, visited map[reflect.Type]bool) bool {
+	// types can be recursive, but a type only needs to be checked once
+	if typ == nil || visited[typ] {
+		return false
+	}
+	visited[typ] = true
+
+	switch typ.Kind() {
+	case reflect.Ptr:
+		return hasRequiredFieldsRecursively(typ.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if _, ok := configTreeFieldPath("", field); !ok {
+				continue
+			}
+			if configTreeFieldHasOption(field, configTreeFieldOptionRequired) ||
+				hasRequiredFieldsRecursively(field.Type, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkRequiredFields returns a RequiredFieldsError if any required field of cfg
+// has a zero value and has been reported as unset by the loader.
+func checkRequiredFields(cfg interface{}, report LoadReport, sources []Source) error {
+	var (
+		missing []string
+		value   = reflect.Indirect(reflect.ValueOf(cfg).Elem())
+	)
+
+	collectMissingRequiredFields("", value, report, &missing)
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	var errs = make(RequiredFieldsError, len(missing))
+	for i, treePath := range missing {
+		errs[i].TreePath = treePath
+		for _, source := range sources {
+			if s, ok := source.(SourceKeyDescriber); ok {
+				errs[i].SourceKeys = append(errs[i].SourceKeys, SourceKey{
+					Source: s.Name(),
+					Key:    s.DescribeKey(treePath),
+				})
+			}
+		}
+	}
+
+	return errs
+}
+
+func collectMissingRequiredFields(path string, v reflect.Value, report LoadReport, missing *[]string) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		// a nil pointor may still have required fields to report
+		if v.IsNil() {
+			v = reflect.Zero(v.Type().Elem())
+		} else {
+			v = v.Elem()
+		}
+		collectMissingRequiredFields(path, v, report, missing)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			var (
+				childV     = v.Field(i)
+				childField = v.Type().Field(i)
+			)
+
+			childPath, ok := configTreeFieldPath(path, childField)
+			if !ok {
+				continue
+			}
+
+			if configTreeFieldHasOption(childField, configTreeFieldOptionRequired) &&
+				!isConfigTreePathSet(report, childPath) && isZeroValue(&childV) {
+				*missing = append(*missing, childPath)
+				// no need to report required fields under a missing one
+				continue
+			}
+
+			collectMissingRequiredFields(childPath, childV, report, missing)
+		}
+	}
+}
+
+// isConfigTreePathSet returns true if the tree path,
+// or any tree path under it, has been set.
+func isConfigTreePathSet(report LoadReport, treePath string) bool {
+	for path, source := range report {
+		if source == LoadReportUnset {
+			continue
+		}
+		if path == treePath || strings.HasPrefix(path, treePath+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSourceThatDescribeKeys struct{ stubSourceThatUseReflection }
+
+func (s stubSourceThatDescribeKeys) Name() string { return "stub describe" }
+
+func (s stubSourceThatDescribeKeys) DescribeKey(treePath string) string { return "KEY_" + treePath }
+
+type requiredCfgNested struct {
+	Required string `cfg:",required"`
+	Optional string
+}
+
+type requiredCfg struct {
+	SetBySource     string            `cfg:"source,required"`
+	SetByDefault    requiredDefaulted `cfg:"default,required"`
+	SetByCaller     string            `cfg:",required"`
+	SetToZero       bool              `cfg:",required"`
+	Missing         string            `cfg:",required"`
+	MissingNested   requiredCfgNested `cfg:",required"`
+	PartiallySet    requiredCfgNested
+	MissingPointer  *requiredCfgNested
+	NotRequired     string
+	DiscardRequired string `cfg:"-,required"`
+}
+
+type requiredDefaulted string
+
+func (d *requiredDefaulted) SetDefault() { *d = "default" }
+
+func Test_Load_required(t *testing.T) {
+	var (
+		source = stubSourceThatDescribeKeys{stubSourceThatUseReflection{
+			"source":                "value",
+			"settozero":             "false",
+			"partiallyset.optional": "value",
+		}}
+		cfg = requiredCfg{SetByCaller: "value"}
+	)
+
+	err := Load(&cfg, WithRawSources(source, stubSourceThatUnmarshal(0)))
+	require.Error(t, err)
+
+	var requiredErr RequiredFieldsError
+	require.True(t, errors.As(err, &requiredErr))
+	assert.Equal(t, RequiredFieldsError{
+		{TreePath: "missing", SourceKeys: []SourceKey{{Source: "stub describe", Key: "KEY_missing"}}},
+		{TreePath: "missingnested", SourceKeys: []SourceKey{{Source: "stub describe", Key: "KEY_missingnested"}}},
+		{TreePath: "partiallyset.required", SourceKeys: []SourceKey{{Source: "stub describe", Key: "KEY_partiallyset.required"}}},
+		{TreePath: "missingpointer.required", SourceKeys: []SourceKey{{Source: "stub describe", Key: "KEY_missingpointer.required"}}},
+	}, requiredErr)
+	assert.Equal(t,
+		"failed to load configuration: missing required fields: "+
+			"missing (stub describe: KEY_missing), "+
+			"missingnested (stub describe: KEY_missingnested), "+
+			"partiallyset.required (stub describe: KEY_partiallyset.required), "+
+			"missingpointer.required (stub describe: KEY_missingpointer.required)",
+		err.Error(),
+	)
+
+	source.stubSourceThatUseReflection["missing"] = "value"
+	source.stubSourceThatUseReflection["missingnested.required"] = "value"
+	source.stubSourceThatUseReflection["partiallyset.required"] = "value"
+	source.stubSourceThatUseReflection["missingpointer.required"] = "value"
+	require.NoError(t, Load(&requiredCfg{SetByCaller: "value"}, WithRawSources(source)))
+}
+
+func Test_RequiredField_String(t *testing.T) {
+	assert.Equal(t, "a.b", RequiredField{TreePath: "a.b"}.String())
+	assert.Equal(t, "a.b (s1: k1, s2: k2)", RequiredField{
+		TreePath:   "a.b",
+		SourceKeys: []SourceKey{{Source: "s1", Key: "k1"}, {Source: "s2", Key: "k2"}},
+	}.String())
+}
+
+func Test_hasRequiredFields(t *testing.T) {
+	type recursive struct {
+		Next *recursive
+	}
+
+	assert.True(t, hasRequiredFields(reflect.TypeOf(&requiredCfg{})))
+	assert.True(t, hasRequiredFields(reflect.TypeOf(requiredCfgNested{})))
+	assert.False(t, hasRequiredFields(reflect.TypeOf(recursive{})))
+	assert.False(t, hasRequiredFields(reflect.TypeOf("")))
+	assert.False(t, hasRequiredFields(nil))
+}
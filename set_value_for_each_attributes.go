@@ -1,6 +1,8 @@
 package config
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,16 +10,35 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/krostar/config/internal/trivialerr"
+	"github.com/krostar/config/trivialerr"
 )
 
 // InitializeNewValueOfTypeWithJSON .
-// nolint: gocyclo
+// Decoding failures are returned as *DecodeError.
 func InitializeNewValueOfTypeWithJSON(typ reflect.Type, jsonData []byte) (*reflect.Value, error) {
 	if typ == nil {
 		return nil, errors.New("cannot create value of nil type")
 	}
 
+	v, err := initializeNewValueOfTypeWithJSON(typ, jsonData)
+	if err != nil {
+		return nil, &DecodeError{Type: typ, RawValue: string(jsonData), Err: err}
+	}
+
+	return v, nil
+}
+
+// nolint: gocyclo
+func initializeNewValueOfTypeWithJSON(typ reflect.Type, jsonData []byte) (*reflect.Value, error) {
+	// if a decoder is registered for this type, and the value is a
+	// string, use the decoder with the unquoted string
+	var str string
+	if bytes.HasPrefix(bytes.TrimSpace(jsonData), []byte(`"`)) && json.Unmarshal(jsonData, &str) == nil {
+		if v, exists, err := decodeWithRegisteredDecoder(typ, str); exists {
+			return v, err
+		}
+	}
+
 	vPtr := reflect.New(typ)
 	v := vPtr.Elem()
 
@@ -50,11 +71,26 @@ func InitializeNewValueOfTypeWithJSON(typ reflect.Type, jsonData []byte) (*refle
 }
 
 // InitializeNewValueOfTypeWithString .
+// Decoding failures are returned as *DecodeError.
 func InitializeNewValueOfTypeWithString(typ reflect.Type, str string) (*reflect.Value, error) {
 	if typ == nil {
 		return nil, errors.New("cannot create value of nil type")
 	}
 
+	// registered decoders take precedence over anything else, then comes
+	// types that know how to decode themselves from their text representation
+	for _, decode := range []func(reflect.Type, string) (*reflect.Value, bool, error){
+		decodeWithRegisteredDecoder,
+		decodeWithTextUnmarshaler,
+	} {
+		if v, exists, err := decode(typ, str); exists {
+			if err != nil {
+				return nil, &DecodeError{Type: typ, RawValue: str, Err: err}
+			}
+			return v, nil
+		}
+	}
+
 	kind := typ.Kind()
 	underTyp := typ
 
@@ -78,12 +114,44 @@ func InitializeNewValueOfTypeWithString(typ reflect.Type, str string) (*reflect.
 		}
 	}
 
+	var jsonData = str
+
 	// if the representation is a string, quote it as json unmarshaller need them
 	if kind == reflect.String {
-		str = strconv.Quote(str)
+		jsonData = strconv.Quote(str)
+	}
+
+	v, err := initializeNewValueOfTypeWithJSON(typ, []byte(jsonData))
+	if err != nil {
+		return nil, &DecodeError{Type: typ, RawValue: str, Err: err}
 	}
 
-	return InitializeNewValueOfTypeWithJSON(typ, []byte(str))
+	return v, nil
+}
+
+// InitializeNewValueOfStructWithJSON creates a new struct value by decoding jsonData onto
+// a copy of the current one, so fields absent from jsonData keep their current values,
+// like values set by defaults or by previous sources.
+// Decoding failures are returned as *DecodeError.
+func InitializeNewValueOfStructWithJSON(current reflect.Value, jsonData []byte) (*reflect.Value, error) {
+	if current.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot decode json onto a value of kind %s", current.Kind())
+	}
+
+	vPtr := reflect.New(current.Type())
+	// the copy is deep, otherwise a failure could leave shared maps half-filled
+	vPtr.Elem().Set(deepCopyValue(current))
+
+	if err := json.Unmarshal(jsonData, vPtr.Interface()); err != nil {
+		return nil, &DecodeError{
+			Type:     current.Type(),
+			RawValue: string(jsonData),
+			Err:      fmt.Errorf("json marshaller failed to fill the value: %w", err),
+		}
+	}
+
+	v := vPtr.Elem()
+	return &v, nil
 }
 
 // SetNewValue .
@@ -98,6 +166,52 @@ func SetNewValue(oldValue, newValue *reflect.Value) (bool, error) {
 }
 
 func setValuesForEachAttributes(src SourceSetValueFromConfigTreePath, cfg interface{}) error {
+	return newConfigTreeSetter(context.Background(), src).setValues(cfg)
+}
+
+// configTreeSetter walks through a configuration and asks its source
+// for the value of each config tree paths.
+type configTreeSetter struct {
+	ctx context.Context
+	src SourceSetValueFromConfigTreePathContext
+	// keys is defined if the source is able to list keys of slices, arrays and maps.
+	keys SourceKeyLister
+	// structs is true if the source wants to be asked for structs as a whole.
+	structs bool
+	// onSet, if defined, is called for each config tree path set by src.
+	onSet func(treePath string)
+	// onError, if defined, is called for each config tree path src failed to set,
+	// instead of stopping at the first failure.
+	onError func(treePath string, err error)
+
+	// field is the last struct field walked through, if any.
+	field *reflect.StructField
+}
+
+// newConfigTreeSetter creates a setter for a source that implements either
+// SourceSetValueFromConfigTreePath or SourceSetValueFromConfigTreePathContext.
+func newConfigTreeSetter(ctx context.Context, source Source) *configTreeSetter {
+	var setter = configTreeSetter{ctx: ctx}
+
+	switch s := source.(type) {
+	case SourceSetValueFromConfigTreePathContext:
+		setter.src = s
+	case SourceSetValueFromConfigTreePath:
+		setter.src = sourceSetValueFromConfigTreePathWithoutContext{s}
+	}
+
+	if s, ok := source.(SourceKeyLister); ok {
+		setter.keys = s
+	}
+
+	if s, ok := source.(SourceSetStructValue); ok {
+		setter.structs = s.SetsStructValue()
+	}
+
+	return &setter
+}
+
+func (s *configTreeSetter) setValues(cfg interface{}) error {
 	var value = reflect.ValueOf(cfg)
 
 	if value.IsNil() {
@@ -105,34 +219,212 @@ func setValuesForEachAttributes(src SourceSetValueFromConfigTreePath, cfg interf
 	}
 
 	value = reflect.Indirect(value.Elem())
-	if _, err := setValueRecursively(src, "", &value); err != nil {
+	if _, err := s.setValueRecursively("", &value); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func setValueRecursively(src SourceSetValueFromConfigTreePath, path string, v *reflect.Value) (bool, error) {
+func (s *configTreeSetter) setValueRecursively(path string, v *reflect.Value) (bool, error) {
 	switch v.Kind() {
 	case reflect.Invalid:
 		return false, errors.New("value is invalid")
 	case reflect.Ptr:
-		return setValuePointor(src, path, v)
+		// pointers with a decoder registered for them are decoded as is
+		if _, exists := lookupDecoder(v.Type()); exists {
+			return s.setValueLeaf(path, v)
+		}
+		return s.setValuePointor(path, v)
 	case reflect.Struct:
-		return setValueStruct(src, path, v)
+		// structs that know how to decode themselves are not walked through
+		if HasStringDecoder(v.Type()) {
+			return s.setValueLeaf(path, v)
+		}
+		return s.setValueStruct(path, v)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return s.setValueCollection(path, v)
 	default:
-		isset, err := src.SetValueFromConfigTreePath(v, path)
+		return s.setValueLeaf(path, v)
+	}
+}
+
+func (s *configTreeSetter) setValueLeaf(path string, v *reflect.Value) (bool, error) {
+	if err := s.ctx.Err(); err != nil {
+		return false, err
+	}
+
+	var ctx = s.ctx
+	if s.field != nil {
+		ctx = contextWithStructField(ctx, *s.field)
+	}
+
+	isset, err := s.src.SetValueFromConfigTreePathContext(ctx, v, path)
+	if err != nil {
+		return false, s.handleError(path, err)
+	}
+
+	if isset && s.onSet != nil {
+		s.onSet(path)
+	}
+
+	return isset, nil
+}
+
+// handleError ignores trivial errors, and reports other errors
+// to onError if defined, or returns them otherwise.
+func (s *configTreeSetter) handleError(path string, err error) error {
+	if trivialerr.IsTrivial(err) {
+		return nil
+	}
+
+	if s.onError != nil {
+		s.onError(path, err)
+		return nil
+	}
+
+	return fmt.Errorf("unable to get value for key %q: %w", path, err)
+}
+
+func (s *configTreeSetter) setValueCollection(path string, v *reflect.Value) (bool, error) {
+	// the whole collection is first asked as one value
+	isSet, err := s.setValueLeaf(path, v)
+	if err != nil || s.keys == nil {
+		return isSet, err
+	}
+
+	// then each of its elements, if the source knows which exists
+	keys, err := s.keys.ListKeys(path)
+	if err != nil {
+		return isSet, s.handleError(path, err)
+	}
+
+	if len(keys) == 0 {
+		return isSet, nil
+	}
+
+	var (
+		onSet     = s.onSet
+		elemIsSet bool
+	)
+
+	// collections are leaves of the config tree: setting
+	// one of their elements is reported as setting them
+	s.onSet = nil
+
+	switch v.Kind() {
+	case reflect.Map:
+		elemIsSet, err = s.setValueMapElements(path, v, keys)
+	default:
+		elemIsSet, err = s.setValueListElements(path, v, keys)
+	}
+
+	s.onSet = onSet
+
+	if elemIsSet && onSet != nil {
+		onSet(path)
+	}
+
+	return isSet || elemIsSet, err
+}
+
+func (s *configTreeSetter) setValueListElements(path string, v *reflect.Value, keys []string) (bool, error) {
+	// keys that are not valid indexes are ignored
+	var (
+		indexes  []int
+		maxIndex = -1
+	)
+
+	for _, key := range keys {
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || (v.Kind() == reflect.Array && index >= v.Len()) {
+			continue
+		}
+
+		indexes = append(indexes, index)
+		if index > maxIndex {
+			maxIndex = index
+		}
+	}
+
+	// work on a copy, that only replaces the original if an element has been set
+	var newV reflect.Value
+	if v.Kind() == reflect.Array {
+		newV = reflect.New(v.Type()).Elem()
+		newV.Set(*v)
+	} else {
+		length := v.Len()
+		if maxIndex >= length {
+			length = maxIndex + 1
+		}
+
+		newV = reflect.MakeSlice(v.Type(), length, length)
+		reflect.Copy(newV, *v)
+	}
+
+	var oneIsSet = false
+
+	for _, index := range indexes {
+		elemV := newV.Index(index)
+
+		isSet, err := s.setValueRecursively(appendConfigTreePath(path, strconv.Itoa(index)), &elemV)
+		if err != nil {
+			return false, err
+		}
+		if isSet {
+			oneIsSet = true
+		}
+	}
+
+	if !oneIsSet {
+		return false, nil
+	}
+
+	return SetNewValue(v, &newV)
+}
+
+func (s *configTreeSetter) setValueMapElements(path string, v *reflect.Value, keys []string) (bool, error) {
+	// work on a copy, that only replaces the original if an element has been set
+	var newV = reflect.MakeMapWithSize(v.Type(), v.Len())
+	for _, key := range v.MapKeys() {
+		newV.SetMapIndex(key, v.MapIndex(key))
+	}
+
+	var oneIsSet = false
+
+	for _, key := range keys {
+		keyV, err := InitializeNewValueOfTypeWithString(v.Type().Key(), key)
 		if err != nil {
-			if !trivialerr.IsTrivial(err) {
-				return false, fmt.Errorf("unable to get value for key %q: %w", path, err)
+			if err = s.handleError(path, fmt.Errorf("invalid key %q: %w", key, err)); err != nil {
+				return false, err
 			}
-			return false, nil
+			continue
+		}
+
+		// map elements are not addressable, so work on a copy of the element
+		elemV := reflect.New(v.Type().Elem()).Elem()
+		if existingV := newV.MapIndex(*keyV); existingV.IsValid() {
+			elemV.Set(existingV)
+		}
+
+		isSet, err := s.setValueRecursively(appendConfigTreePath(path, key), &elemV)
+		if err != nil {
+			return false, err
+		}
+		if isSet {
+			newV.SetMapIndex(*keyV, elemV)
+			oneIsSet = true
 		}
-		return isset, nil
 	}
+
+	if !oneIsSet {
+		return false, nil
+	}
+
+	return SetNewValue(v, &newV)
 }
 
-func setValuePointor(src SourceSetValueFromConfigTreePath, path string, v *reflect.Value) (bool, error) {
+func (s *configTreeSetter) setValuePointor(path string, v *reflect.Value) (bool, error) {
 	var validV = *v
 
 	// if we have a nil pointor, build a non-nil one
@@ -144,7 +436,7 @@ func setValuePointor(src SourceSetValueFromConfigTreePath, path string, v *refle
 	newV := validV.Elem()
 
 	// go recursively with the pointed value
-	if isSet, err := setValueRecursively(src, path, &newV); err != nil || !isSet {
+	if isSet, err := s.setValueRecursively(path, &newV); err != nil || !isSet {
 		return false, err
 	}
 
@@ -157,31 +449,34 @@ func setValuePointor(src SourceSetValueFromConfigTreePath, path string, v *refle
 	return true, nil
 }
 
-func setValueStruct(src SourceSetValueFromConfigTreePath, path string, v *reflect.Value) (bool, error) {
-	const tagKey = "cfg"
-
+func (s *configTreeSetter) setValueStruct(path string, v *reflect.Value) (bool, error) {
 	var oneIsSet = false
 
+	// the whole struct is first asked as one value, if the source wants to
+	if s.structs && path != "" {
+		isSet, err := s.setValueWholeStruct(path, v)
+		if err != nil {
+			return false, err
+		}
+		oneIsSet = isSet
+	}
+
 	for i := 0; i < v.NumField(); i++ {
-		// get info of each fields as for example the real name of the field
 		var (
 			childV     = v.Field(i)
 			childField = v.Type().Field(i)
-			childPath  = appendConfigTreePath(path, childField.Name)
 		)
 
-		// if a tag is defined, override the name with it
-		if tag := childField.Tag.Get(tagKey); tag != "" {
-			childPath = appendConfigTreePath(path, tag)
-		}
-
-		// ignore if the field is unexported or the name is `-`
-		if childField.PkgPath != "" || path == "-" {
+		// get the path of the field, and ignore it if it should be
+		childPath, ok := configTreeFieldPath(path, childField)
+		if !ok {
 			continue
 		}
 
+		s.field = &childField
+
 		// recursive call with the value
-		if isSet, err := setValueRecursively(src, childPath, &childV); err == nil {
+		if isSet, err := s.setValueRecursively(childPath, &childV); err == nil {
 			if isSet {
 				oneIsSet = true
 			}
@@ -192,3 +487,32 @@ func setValueStruct(src SourceSetValueFromConfigTreePath, path string, v *reflec
 
 	return oneIsSet, nil
 }
+
+// setValueWholeStruct asks the source for the whole struct as one value. As a
+// struct is not a leaf of the config tree, only its leaves that changed are
+// reported as set.
+func (s *configTreeSetter) setValueWholeStruct(path string, v *reflect.Value) (bool, error) {
+	var (
+		onSet  = s.onSet
+		before = make(configTreeSnapshot)
+	)
+
+	before.takeRecursively(path, *v, true)
+
+	s.onSet = nil
+	isSet, err := s.setValueLeaf(path, v)
+	s.onSet = onSet
+
+	if err != nil || !isSet || onSet == nil {
+		return isSet, err
+	}
+
+	after := make(configTreeSnapshot)
+	after.takeRecursively(path, *v, true)
+
+	for _, treePath := range before.changedPaths(after) {
+		onSet(treePath)
+	}
+
+	return isSet, nil
+}
@@ -1,12 +1,17 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"net/url"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/krostar/config/trivialerr"
 )
 
 func Test_InitializeNewValueOfTypeWithJSON(t *testing.T) {
@@ -185,6 +190,11 @@ func Test_InitializeNewValueOfTypeWithString(t *testing.T) {
 			value, err := InitializeNewValueOfTypeWithString(test.valueType, test.valueRepr)
 			if test.expectedFailure {
 				require.Error(t, err)
+				if test.valueType != nil {
+					var decodeErr *DecodeError
+					require.True(t, errors.As(err, &decodeErr))
+					assert.Equal(t, test.valueRepr, decodeErr.RawValue)
+				}
 			} else {
 				require.NoError(t, err)
 				require.Equal(t, test.expectedValue, reflect.Indirect(*value).Interface())
@@ -193,6 +203,35 @@ func Test_InitializeNewValueOfTypeWithString(t *testing.T) {
 	}
 }
 
+func Test_InitializeNewValueOfStructWithJSON(t *testing.T) {
+	type database struct {
+		Host   string
+		Port   int
+		Labels map[string]string
+	}
+
+	var current = database{Port: 5432, Labels: map[string]string{"a": "b"}}
+
+	v, err := InitializeNewValueOfStructWithJSON(reflect.ValueOf(current), []byte(`{"host": "db", "labels": {"c": "d"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, database{
+		Host:   "db",
+		Port:   5432,
+		Labels: map[string]string{"a": "b", "c": "d"},
+	}, v.Interface())
+	// the current value is left untouched
+	assert.Equal(t, map[string]string{"a": "b"}, current.Labels)
+
+	_, err = InitializeNewValueOfStructWithJSON(reflect.ValueOf(current), []byte(`{"labels": {"e": 1}}`))
+	require.Error(t, err)
+	var decodeErr *DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, map[string]string{"a": "b"}, current.Labels)
+
+	_, err = InitializeNewValueOfStructWithJSON(reflect.ValueOf(42), []byte("42"))
+	require.Error(t, err)
+}
+
 func Test_setValuesForEachAttributes(t *testing.T) {
 	type (
 		icfgNested struct {
@@ -270,3 +309,135 @@ func Test_setValuesForEachAttributes(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, expectedCfg, cfg)
 }
+
+func Test_setValuesForEachAttributes_with_keys(t *testing.T) {
+	type (
+		server struct {
+			Host string
+			Port int
+		}
+
+		icfg struct {
+			Servers        []server
+			PtrServers     []*server
+			Hosts          [2]string
+			Tenants        map[string]server
+			PtrTenants     map[string]*server
+			Untouched      []server
+			UntouchedMap   map[string]string
+			IgnoredIndexes []string
+			Ports          map[int]int
+		}
+	)
+
+	var (
+		source = stubSourceThatListKeys{stubSourceThatUseReflection: stubSourceThatUseReflection{
+			"servers.0.host":       "a",
+			"servers.2.port":       "3",
+			"ptrservers.1.host":    "b",
+			"hosts.1":              "h",
+			"hosts.2":              "out of bounds",
+			"tenants.acme.host":    "acme",
+			"tenants.other.port":   "42",
+			"ptrtenants.acme.host": "acme",
+			"ignoredindexes.-1":    "negative",
+			"ignoredindexes.a":     "not a number",
+			"ports.80":             "8080",
+		}}
+		cfg = icfg{
+			Servers:      []server{{Host: "z", Port: 1}},
+			Untouched:    []server{{Host: "untouched"}},
+			UntouchedMap: map[string]string{"hello": "world"},
+			Tenants:      map[string]server{"acme": {Port: 1}, "initial": {Port: 2}},
+		}
+	)
+
+	require.NoError(t, setValuesForEachAttributes(source, &cfg))
+	assert.Equal(t, icfg{
+		Servers:    []server{{Host: "a", Port: 1}, {}, {Port: 3}},
+		PtrServers: []*server{nil, {Host: "b"}},
+		Hosts:      [2]string{"", "h"},
+		Tenants: map[string]server{
+			"acme":    {Host: "acme", Port: 1},
+			"other":   {Port: 42},
+			"initial": {Port: 2},
+		},
+		PtrTenants:   map[string]*server{"acme": {Host: "acme"}},
+		Untouched:    []server{{Host: "untouched"}},
+		UntouchedMap: map[string]string{"hello": "world"},
+		Ports:        map[int]int{80: 8080},
+	}, cfg)
+
+	// invalid map keys
+	var withInvalidKeys struct {
+		Ports map[int]int
+	}
+	source = stubSourceThatListKeys{stubSourceThatUseReflection: stubSourceThatUseReflection{
+		"ports.http": "8080",
+	}}
+	require.Error(t, setValuesForEachAttributes(source, &withInvalidKeys))
+
+	// failure to list keys
+	source.err = errors.New("boum")
+	require.Error(t, setValuesForEachAttributes(source, &withInvalidKeys))
+	source.err = trivialerr.New("boum")
+	require.NoError(t, setValuesForEachAttributes(source, &withInvalidKeys))
+
+	// failure to set an element
+	source = stubSourceThatListKeys{stubSourceThatUseReflection: stubSourceThatUseReflection{
+		"ports.80": "http",
+	}}
+	require.Error(t, setValuesForEachAttributes(source, &withInvalidKeys))
+	var withInvalidIndexes struct {
+		Ports []int
+	}
+	source = stubSourceThatListKeys{stubSourceThatUseReflection: stubSourceThatUseReflection{
+		"ports.0": "http",
+	}}
+	require.Error(t, setValuesForEachAttributes(source, &withInvalidIndexes))
+}
+
+func Test_setValuesForEachAttributes_with_structs(t *testing.T) {
+	type (
+		database struct {
+			Host string
+			Port int
+		}
+
+		icfg struct {
+			Database database
+			Endpoint url.URL
+			Ignored  database
+		}
+	)
+
+	var (
+		values = stubSourceThatUseReflection{
+			"database":      `{"host": "db", "port": 1}`,
+			"database.port": "2",
+			"endpoint":      "http://localhost",
+		}
+		expectedURL = url.URL{Scheme: "http", Host: "localhost"}
+		cfg         icfg
+	)
+
+	// structs are not asked as a whole by default, except decodable ones
+	require.NoError(t, setValuesForEachAttributes(values, &cfg))
+	assert.Equal(t, icfg{Database: database{Port: 2}, Endpoint: expectedURL}, cfg)
+
+	cfg = icfg{}
+	require.NoError(t, setValuesForEachAttributes(stubSourceThatSetStructs{values}, &cfg))
+	assert.Equal(t, icfg{Database: database{Host: "db", Port: 2}, Endpoint: expectedURL}, cfg)
+
+	// structs are not leaves, only their leaves that changed are reported (the
+	// port once by the whole struct and once by itself, but never the host)
+	var reported []string
+	cfg = icfg{Database: database{Host: "db"}}
+	setter := newConfigTreeSetter(context.Background(), stubSourceThatSetStructs{values})
+	setter.onSet = func(treePath string) { reported = append(reported, treePath) }
+	require.NoError(t, setter.setValues(&cfg))
+	assert.ElementsMatch(t, []string{"database.port", "database.port", "endpoint"}, reported)
+
+	values["database"] = `{"port": "not a number"}`
+	require.Error(t, setValuesForEachAttributes(stubSourceThatSetStructs{values}, &cfg))
+}
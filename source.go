@@ -1,7 +1,9 @@
 package config
 
 import (
+	"context"
 	"reflect"
+	"sort"
 	"strings"
 )
 
@@ -9,6 +11,8 @@ import (
 type SourceCreationFunc func() (Source, error)
 
 // Source defines the interface any source must implements.
+// Sources that have nothing to load, or no value for a key, should
+// return trivial errors (see the trivialerr package) to let the load continue.
 type Source interface {
 	Name() string
 }
@@ -20,6 +24,13 @@ type SourceUnmarshal interface {
 	Unmarshal(cfg interface{}) error
 }
 
+// SourceUnmarshalContext is like SourceUnmarshal, but
+// the unmarshalling can be cancelled through the context.
+type SourceUnmarshalContext interface {
+	Source
+	UnmarshalContext(ctx context.Context, cfg interface{}) error
+}
+
 // SourceSetValueFromConfigTreePath defines a way to set explicitly
 // each values from each configuration paths.
 type SourceSetValueFromConfigTreePath interface {
@@ -27,6 +38,158 @@ type SourceSetValueFromConfigTreePath interface {
 	SetValueFromConfigTreePath(v *reflect.Value, treePath string) (bool, error)
 }
 
+// SourceSetValueFromConfigTreePathContext is like SourceSetValueFromConfigTreePath,
+// but the retrieval of each values can be cancelled through the context.
+type SourceSetValueFromConfigTreePathContext interface {
+	Source
+	SetValueFromConfigTreePathContext(ctx context.Context, v *reflect.Value, treePath string) (bool, error)
+}
+
+// SourceKeyLister defines a way for a source that sets values from config tree
+// paths to tell which keys exist under a config tree path. It allows slices,
+// arrays and maps elements to be set one by one: for example if ListKeys("servers")
+// returns "0" and "1", the loader will ask for "servers.0.host", "servers.1.host", ...
+// and if ListKeys("tenants") returns "acme", it will ask for "tenants.acme.dsn".
+// Keys that are not valid slices or arrays indexes are ignored.
+type SourceKeyLister interface {
+	Source
+	ListKeys(treePath string) ([]string, error)
+}
+
+// TrimTrailingNewline helps sources that read values from files: it returns
+// the content without its trailing newline, if any, which editors often add.
+func TrimTrailingNewline(content string) string {
+	if strings.HasSuffix(content, "\r\n") {
+		return content[:len(content)-2]
+	}
+	return strings.TrimSuffix(content, "\n")
+}
+
+// ListKeysOfPaths helps sources implement SourceKeyLister: it returns, sorted and
+// without duplicates, the part that directly follows the parent path in any of the
+// paths, parts being separated by sep. Given "servers.0.host" and "servers.1.host",
+// ListKeysOfPaths("servers", ".", paths) returns "0" and "1".
+func ListKeysOfPaths(parent, sep string, paths []string) []string {
+	var (
+		prefix = parent + sep
+		keys   []string
+		seen   = make(map[string]bool)
+	)
+
+	for _, path := range paths {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+
+		key := strings.SplitN(path[len(prefix):], sep, 2)[0]
+		if key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// SourceSetStructValue defines a way for a source that sets values from config tree
+// paths to opt in to be asked for values of structs as a whole (except the root one),
+// before being asked for each of their fields. It allows a source to set a whole
+// struct at once, while still allowing it to override some fields.
+type SourceSetStructValue interface {
+	Source
+	SetsStructValue() bool
+}
+
+// SourceRefresher defines a way for a source to refresh what it knows before each
+// load, like re-reading a file, which allows sources that set values from config
+// tree paths to read their content at load time. Errors are handled like loading
+// errors: trivial ones are ignored, and the source is not loaded.
+type SourceRefresher interface {
+	Source
+	Refresh(ctx context.Context) error
+}
+
+type structFieldContextKey struct{}
+
+// StructFieldFromContext returns the struct field whose value is asked to a
+// SourceSetValueFromConfigTreePathContext source, if the value is a struct
+// field, or if it is pointed by one. It allows sources to read field's tags.
+func StructFieldFromContext(ctx context.Context) (reflect.StructField, bool) {
+	field, ok := ctx.Value(structFieldContextKey{}).(reflect.StructField)
+	return field, ok
+}
+
+func contextWithStructField(ctx context.Context, field reflect.StructField) context.Context {
+	return context.WithValue(ctx, structFieldContextKey{}, field)
+}
+
+// SourceWatcher defines a way for a source to notify changes,
+// allowing the configuration to be reloaded (see Config.Watch).
+// Watch should call notify each time the source changes, and
+// only return once ctx is done or if watching is impossible.
+type SourceWatcher interface {
+	Source
+	Watch(ctx context.Context, notify func()) error
+}
+
+// SourceKeyDescriber defines a way for a source to tell which
+// key it looks up to find the value of a config tree path.
+// It is used to give helpful errors, for example when a required
+// value is not set (see RequiredFieldsError).
+type SourceKeyDescriber interface {
+	Source
+	DescribeKey(treePath string) string
+}
+
+// sourceSetValueFromConfigTreePathWithoutContext adapts a
+// SourceSetValueFromConfigTreePath to SourceSetValueFromConfigTreePathContext.
+type sourceSetValueFromConfigTreePathWithoutContext struct {
+	SourceSetValueFromConfigTreePath
+}
+
+func (s sourceSetValueFromConfigTreePathWithoutContext) SetValueFromConfigTreePathContext(
+	_ context.Context, v *reflect.Value, treePath string,
+) (bool, error) {
+	return s.SetValueFromConfigTreePath(v, treePath)
+}
+
+// configTreeFieldTagKey is the struct tag key used to customize the configuration
+// of a field; it is made of the name of the field, followed by comma-separated
+// options, for example `cfg:"name,required"`.
+const configTreeFieldTagKey = "cfg"
+
+// configTreeFieldHasOption returns true if the field's tag contains the provided option.
+func configTreeFieldHasOption(field reflect.StructField, option string) bool {
+	for _, opt := range strings.Split(field.Tag.Get(configTreeFieldTagKey), ",")[1:] {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// configTreeFieldPath returns the config tree path of a struct field given
+// the path of its parent. If the field should not be part of the configuration
+// (unexported, or with the `cfg:"-"` tag), false is returned.
+func configTreeFieldPath(parentPath string, field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		return "", false
+	}
+
+	var name = field.Name
+
+	// if a tag is defined, override the name with it
+	if tag := strings.Split(field.Tag.Get(configTreeFieldTagKey), ",")[0]; tag == "-" {
+		return "", false
+	} else if tag != "" {
+		name = tag
+	}
+
+	return appendConfigTreePath(parentPath, name), true
+}
+
 func appendConfigTreePath(parentPath string, childName string) string {
 	if parentPath != "" {
 		childName = parentPath + "." + childName
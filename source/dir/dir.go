@@ -0,0 +1,98 @@
+// Package sourcedir sources configuration from every file of a
+// directory, like conf.d directories.
+package sourcedir
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/afero"
+
+	"github.com/krostar/config"
+	sourcefile "github.com/krostar/config/source/file"
+	"github.com/krostar/config/trivialerr"
+)
+
+// Dir implements config.Source to fetch values from the
+// files of a directory that match a pattern.
+type Dir struct {
+	fs afero.Fs
+
+	dir     string
+	pattern string
+
+	strictOpen  bool
+	fileOptions []sourcefile.Option
+}
+
+// New returns a new directory source. Files of dir that match the pattern
+// (see filepath.Match), like "*.yaml", are applied in lexical order, each
+// one overriding values set by the previous ones. Files are read with the
+// file source, which means their format depends on their extension.
+func New(dir string, pattern string, opts ...Option) config.SourceCreationFunc {
+	return func() (config.Source, error) {
+		d := Dir{
+			fs: afero.NewReadOnlyFs(afero.NewOsFs()),
+
+			dir:     dir,
+			pattern: pattern,
+
+			strictOpen: true,
+		}
+
+		for _, opt := range opts {
+			opt(&d)
+		}
+
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+
+		return &d, nil
+	}
+}
+
+// Name implements config.Source interface.
+func (d *Dir) Name() string { return "dir" }
+
+// DescribeKey implements config.SourceKeyDescriber interface.
+// Like the file source, it labels the tree path as such.
+func (d *Dir) DescribeKey(treePath string) string {
+	return filepath.Join(d.dir, d.pattern) + " (tree path " + treePath + ")"
+}
+
+// Unmarshal unmarshals each file of the directory that match the pattern
+// to the provided interface, in lexical order. It returns a trivial error
+// if the directory does not exist and MayNotExist is used.
+func (d *Dir) Unmarshal(to interface{}) error {
+	if _, err := d.fs.Stat(d.dir); err != nil {
+		return trivialerr.WrapIf(d.strictOpen, fmt.Errorf("unable to open directory: %w", err))
+	}
+
+	paths, err := afero.Glob(d.fs, filepath.Join(d.dir, d.pattern))
+	if err != nil {
+		return fmt.Errorf("unable to list files of directory %q: %w", d.dir, err)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if info, err := d.fs.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+
+		opts := append([]sourcefile.Option{sourcefile.WithFs(d.fs)}, d.fileOptions...)
+
+		file, err := sourcefile.New(path, opts...)()
+		if err != nil {
+			return fmt.Errorf("unable to create source for file %q: %w", path, err)
+		}
+
+		if err := file.(*sourcefile.File).Unmarshal(to); err != nil {
+			// file errors already tell which file failed
+			return fmt.Errorf("unable to load directory %q: %w", d.dir, err)
+		}
+	}
+
+	return nil
+}
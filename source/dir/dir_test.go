@@ -0,0 +1,140 @@
+package sourcedir
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/krostar/config"
+	sourcefile "github.com/krostar/config/source/file"
+	"github.com/krostar/config/trivialerr"
+)
+
+func newDir(t *testing.T, dir, pattern string, opts ...Option) *Dir {
+	d, err := New(dir, pattern, opts...)()
+	require.NoError(t, err)
+	return d.(*Dir)
+}
+
+type dirCfg struct {
+	Name  string `json:"name" yaml:"name"`
+	Port  int    `json:"port" yaml:"port"`
+	Debug bool   `json:"debug" yaml:"debug"`
+}
+
+func Test_New(t *testing.T) {
+	d := newDir(t, "conf.d", "*.yaml")
+	assert.Equal(t, "dir", d.Name())
+	assert.Equal(t, "conf.d/*.yaml (tree path http.listenaddress)", d.DescribeKey("http.listenaddress"))
+	assert.True(t, d.strictOpen)
+
+	_, err := New("conf.d", "[")()
+	require.Error(t, err)
+}
+
+func TestDir_Unmarshal(t *testing.T) {
+	var tests = map[string]struct {
+		files                  map[string]string
+		pattern                string
+		opts                   []Option
+		expected               dirCfg
+		expectedFailure        string
+		expectedTrivialFailure bool
+	}{
+		"files are applied in lexical order": {
+			files: map[string]string{
+				"conf.d/20-port.yaml":  "port: 2",
+				"conf.d/10-base.json":  `{"name": "base", "port": 1}`,
+				"conf.d/30-debug.yml":  "debug: true",
+				"conf.d/sub.yaml/file": "port: 3",
+			},
+			pattern:  "*",
+			expected: dirCfg{Name: "base", Port: 2, Debug: true},
+		}, "matching files of unknown format": {
+			files: map[string]string{
+				"conf.d/10-base.json": `{"name": "base", "port": 1}`,
+				"conf.d/ignored.txt":  "not a config",
+			},
+			pattern: "*",
+			expectedFailure: `unable to load directory "conf.d": failed to unmarshal file "conf.d/ignored.txt": ` +
+				`"txt" extension is not supported, and no format is able to decode the file`,
+		}, "only matching files are applied": {
+			files: map[string]string{
+				"conf.d/20-port.yaml": "port: 2",
+				"conf.d/10-base.json": `{"name": "base", "port": 1}`,
+				"conf.d/30-debug.yml": "debug: true",
+				"conf.d/ignored.txt":  "not a config",
+			},
+			pattern:  "*.y*ml",
+			expected: dirCfg{Port: 2, Debug: true},
+		}, "no matching files": {
+			files:    map[string]string{"conf.d/ignored.txt": "not a config"},
+			pattern:  "*.yaml",
+			expected: dirCfg{},
+		}, "failing file": {
+			files: map[string]string{
+				"conf.d/10-base.yaml":  "port: 1",
+				"conf.d/20-port.yaml":  "port: [",
+				"conf.d/30-debug.yaml": "debug: true",
+			},
+			pattern:         "*.yaml",
+			expectedFailure: `unable to load directory "conf.d": failed to unmarshal file "conf.d/20-port.yaml"`,
+		}, "file options are applied": {
+			files:           map[string]string{"conf.d/base.yaml": "unknown: 1"},
+			pattern:         "*.yaml",
+			opts:            []Option{WithFileOptions(sourcefile.FailOnUnknownFields())},
+			expectedFailure: `unable to load directory "conf.d": failed to unmarshal file "conf.d/base.yaml"`,
+		}, "missing directory": {
+			pattern:         "*.yaml",
+			expectedFailure: "unable to open directory",
+		}, "missing directory with lazy opts": {
+			pattern:                "*.yaml",
+			opts:                   []Option{MayNotExist()},
+			expectedFailure:        "unable to open directory",
+			expectedTrivialFailure: true,
+		},
+	}
+
+	for name, test := range tests {
+		var test = test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var fs = afero.NewMemMapFs()
+			for path, content := range test.files {
+				require.NoError(t, afero.WriteFile(fs, path, []byte(content), 0600))
+			}
+
+			var (
+				d  = newDir(t, "conf.d", test.pattern, append(test.opts, WithFs(fs))...)
+				to dirCfg
+			)
+
+			err := d.Unmarshal(&to)
+			if test.expectedFailure != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedFailure)
+				assert.Equal(t, test.expectedTrivialFailure, trivialerr.IsTrivial(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, to)
+		})
+	}
+}
+
+func Test_Load(t *testing.T) {
+	var fs = afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "config.yaml", []byte("name: base\nport: 1"), 0600))
+	require.NoError(t, afero.WriteFile(fs, "conf.d/override.yaml", []byte("port: 2"), 0600))
+
+	var cfg dirCfg
+	require.NoError(t, config.Load(&cfg, config.WithSources(
+		sourcefile.New("config.yaml", sourcefile.WithFs(fs)),
+		New("conf.d", "*.yaml", WithFs(fs)),
+		New("missing.d", "*.yaml", WithFs(fs), MayNotExist()),
+	)))
+	assert.Equal(t, dirCfg{Name: "base", Port: 2}, cfg)
+}
@@ -0,0 +1,28 @@
+package sourcedir
+
+import (
+	"github.com/spf13/afero"
+
+	sourcefile "github.com/krostar/config/source/file"
+)
+
+// Option defines the function signature to apply options.
+type Option func(d *Dir)
+
+// MayNotExist tells the dir.Unmarshal function to return an
+// error that implements IsTrivial when the directory does not exist.
+func MayNotExist() Option {
+	return func(d *Dir) { d.strictOpen = false }
+}
+
+// WithFs sets the filesystem the directory is read from,
+// which is the read-only os filesystem by default.
+func WithFs(fs afero.Fs) Option {
+	return func(d *Dir) { d.fs = fs }
+}
+
+// WithFileOptions sets options applied to each file of the
+// directory, like sourcefile.FailOnUnknownFields.
+func WithFileOptions(opts ...sourcefile.Option) Option {
+	return func(d *Dir) { d.fileOptions = append(d.fileOptions, opts...) }
+}
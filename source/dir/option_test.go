@@ -0,0 +1,32 @@
+package sourcedir
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	sourcefile "github.com/krostar/config/source/file"
+)
+
+func Test_MayNotExist(t *testing.T) {
+	d := newDir(t, "", "*")
+	assert.True(t, d.strictOpen)
+	MayNotExist()(d)
+	assert.False(t, d.strictOpen)
+}
+
+func Test_WithFs(t *testing.T) {
+	var fs = afero.NewMemMapFs()
+
+	d := newDir(t, "", "*")
+	WithFs(fs)(d)
+	assert.Equal(t, fs, d.fs)
+}
+
+func Test_WithFileOptions(t *testing.T) {
+	d := newDir(t, "", "*")
+	WithFileOptions(sourcefile.MayNotExist())(d)
+	WithFileOptions(sourcefile.FailOnUnknownFields())(d)
+	assert.Len(t, d.fileOptions, 2)
+}
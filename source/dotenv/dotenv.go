@@ -0,0 +1,107 @@
+/*
+Package sourcedotenv sources configuration from dotenv (.env) files.
+
+The following syntax is supported:
+
+	# comments, and empty lines, are ignored
+	KEY=unquoted value # with inline comment
+	export KEY=value
+	KEY='single quoted, taken as is,
+	  even on multiple lines'
+	KEY="double quoted, with \n \t \" \\ \$ escapes,
+	  and ${VAR} or $VAR expansion"
+
+Variables are expanded, in unquoted and double quoted values, with variables
+previously defined in the file, or with the process environment. Undefined
+variables are expanded to the empty string.
+*/
+package sourcedotenv
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/afero"
+
+	"github.com/krostar/config"
+	sourceenv "github.com/krostar/config/source/env"
+)
+
+// Dotenv implements config.Source to fetch values from a dotenv file. Variables
+// are looked up exactly like the env source does, but in the file instead of the
+// process environment, which is never modified. The file is read before each load.
+type Dotenv struct {
+	*sourceenv.Env
+
+	fs         afero.Fs
+	path       string
+	prefix     string
+	strictOpen bool
+	envOptions []sourceenv.Option
+}
+
+// New returns a new dotenv source, that reads variables from the file at path
+// (see the package documentation for the syntax) and looks them up with the
+// prefix, like the env source does.
+func New(path string, prefix string, opts ...Option) config.SourceCreationFunc {
+	return func() (config.Source, error) {
+		d := Dotenv{
+			fs:         afero.NewReadOnlyFs(afero.NewOsFs()),
+			path:       path,
+			prefix:     prefix,
+			strictOpen: true,
+		}
+
+		for _, opt := range opts {
+			opt(&d)
+		}
+
+		// the file is read at load time, until then there are no variables
+		if err := d.setVars(nil); err != nil {
+			return nil, err
+		}
+
+		return &d, nil
+	}
+}
+
+// Refresh implements config.SourceRefresher interface, the file is read
+// again before each load.
+func (d *Dotenv) Refresh(context.Context) error {
+	content, err := afero.ReadFile(d.fs, d.path)
+	if err != nil {
+		if !d.strictOpen && os.IsNotExist(err) {
+			return d.setVars(nil)
+		}
+		return fmt.Errorf("unable to read dotenv file: %w", err)
+	}
+
+	vars, err := parse(string(content), os.LookupEnv)
+	if err != nil {
+		return fmt.Errorf("unable to parse dotenv file %q: %w", d.path, err)
+	}
+
+	return d.setVars(vars)
+}
+
+// setVars replaces the embedded env source by one that looks variables up in vars.
+func (d *Dotenv) setVars(vars map[string]string) error {
+	opts := append(append([]sourceenv.Option{}, d.envOptions...), sourceenv.FromMap(vars))
+
+	env, err := sourceenv.New(d.prefix, opts...)()
+	if err != nil {
+		return err
+	}
+	d.Env = env.(*sourceenv.Env)
+
+	return nil
+}
+
+// Name implements config.Source interface.
+func (d *Dotenv) Name() string { return "dotenv" }
+
+// DescribeKey implements config.SourceKeyDescriber interface.
+func (d *Dotenv) DescribeKey(treePath string) string {
+	return d.path + ":" + d.Env.DescribeKey(treePath)
+}
@@ -0,0 +1,149 @@
+package sourcedotenv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/krostar/config"
+	"github.com/krostar/config/configtest"
+	sourceenv "github.com/krostar/config/source/env"
+)
+
+func newDotenv(t *testing.T, path, prefix string, opts ...Option) *Dotenv {
+	d, err := New(path, prefix, opts...)()
+	require.NoError(t, err)
+	return d.(*Dotenv)
+}
+
+func TestDotenv_conformance(t *testing.T) {
+	configtest.RunSourceSuite(t, func(t *testing.T, values map[string]interface{}) config.Source {
+		var lines []string
+		for treePath, value := range configtest.Flatten(values) {
+			key := strings.ToUpper("app_" + strings.ReplaceAll(treePath, ".", "_"))
+			lines = append(lines, fmt.Sprintf("%s=%q", key, value))
+		}
+		sort.Strings(lines)
+
+		fs := afero.NewMemMapFs()
+		configtest.WriteFile(t, fs, ".env", strings.Join(lines, "\n"))
+
+		return newDotenv(t, ".env", "app", WithFs(fs))
+	})
+}
+
+func Test_New(t *testing.T) {
+	var fs = afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, ".env", []byte("APP_NAME=app"), 0600))
+	require.NoError(t, afero.WriteFile(fs, "invalid.env", []byte("APP_NAME='app"), 0600))
+
+	d := newDotenv(t, ".env", "app", WithFs(fs))
+	assert.Equal(t, "dotenv", d.Name())
+	assert.Equal(t, ".env:APP_HTTP_LISTENADDRESS", d.DescribeKey("http.listenaddress"))
+
+	// the file is only read at load time
+	d = newDotenv(t, "missing.env", "app", WithFs(fs))
+	require.Error(t, d.Refresh(context.Background()))
+
+	d = newDotenv(t, "missing.env", "app", WithFs(fs), MayNotExist())
+	require.NoError(t, d.Refresh(context.Background()))
+	keys, err := d.ListKeys("servers")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	d = newDotenv(t, "invalid.env", "app", WithFs(fs))
+	require.EqualError(t, d.Refresh(context.Background()),
+		`unable to parse dotenv file "invalid.env": line 1: invalid value of variable APP_NAME: unterminated single quoted value`,
+	)
+}
+
+func Test_Load(t *testing.T) {
+	type cfg struct {
+		Name    string
+		Timeout time.Duration
+		Hosts   []string
+		Servers []struct{ Host string }
+		TLS     struct{ Cert string }
+	}
+
+	var fs = afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, ".env", []byte(`
+# local development
+export APP_NAME=app
+APP_TIMEOUT=3s
+APP_HOSTS="a,b"
+APP_SERVERS_1_HOST=${APP_NAME}.local
+APP_TLS_CERT="-----BEGIN CERTIFICATE-----
+MII...
+-----END CERTIFICATE-----"
+`), 0600))
+
+	var c cfg
+	require.NoError(t, config.Load(&c, config.WithSources(New(".env", "app", WithFs(fs)))))
+	assert.Equal(t, "app", c.Name)
+	assert.Equal(t, 3*time.Second, c.Timeout)
+	assert.Equal(t, []string{"a", "b"}, c.Hosts)
+	assert.Equal(t, []struct{ Host string }{{}, {Host: "app.local"}}, c.Servers)
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----\nMII...\n-----END CERTIFICATE-----", c.TLS.Cert)
+
+	_, exists := os.LookupEnv("APP_NAME")
+	assert.False(t, exists, "process environment should not be modified")
+}
+
+func Test_Load_refresh(t *testing.T) {
+	var (
+		fs     = afero.NewMemMapFs()
+		source = New(".env", "app", WithFs(fs))
+		c      struct{ Name string }
+	)
+
+	cfg, err := config.New(config.WithSources(source))
+	require.NoError(t, err)
+
+	require.NoError(t, afero.WriteFile(fs, ".env", []byte("APP_NAME=first"), 0600))
+	require.NoError(t, cfg.Load(&c))
+	assert.Equal(t, "first", c.Name)
+
+	// each load reads the file again
+	require.NoError(t, afero.WriteFile(fs, ".env", []byte("APP_NAME=second"), 0600))
+	require.NoError(t, cfg.Load(&c))
+	assert.Equal(t, "second", c.Name)
+
+	require.NoError(t, fs.Remove(".env"))
+	err = cfg.Load(&c)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to read dotenv file")
+}
+
+func Test_Load_envOptions(t *testing.T) {
+	type cfg struct {
+		Database struct {
+			Host     string
+			Port     int `default:"5432"`
+			Password string
+		}
+	}
+
+	var fs = afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "password", []byte("secret\n"), 0600))
+	require.NoError(t, afero.WriteFile(fs, ".env", []byte(`APP_DATABASE='{"host": "db"}'
+APP_DATABASE_PASSWORD_FILE=password`), 0600))
+
+	var c cfg
+	require.NoError(t, config.Load(&c, config.WithSources(New(".env", "app", WithFs(fs), WithEnvOptions(
+		sourceenv.ReadStructsAsJSON(),
+		sourceenv.ReadFileSuffix(),
+		sourceenv.WithFs(fs),
+	)))))
+	assert.Equal(t, "db", c.Database.Host)
+	assert.Equal(t, 5432, c.Database.Port)
+	assert.Equal(t, "secret", c.Database.Password)
+}
@@ -0,0 +1,29 @@
+package sourcedotenv
+
+import (
+	"github.com/spf13/afero"
+
+	sourceenv "github.com/krostar/config/source/env"
+)
+
+// Option defines the function signature to apply options.
+type Option func(d *Dotenv)
+
+// MayNotExist tells the dotenv source to act as an empty
+// file instead of failing when the file does not exist.
+func MayNotExist() Option {
+	return func(d *Dotenv) { d.strictOpen = false }
+}
+
+// WithFs sets the filesystem the file is read from,
+// which is the read-only os filesystem by default.
+func WithFs(fs afero.Fs) Option {
+	return func(d *Dotenv) { d.fs = fs }
+}
+
+// WithEnvOptions sets options of the env source used to look variables up,
+// like sourceenv.ReadStructsAsJSON or sourceenv.ReadFileSuffix. The
+// sourceenv.FromMap option is ignored as variables come from the file.
+func WithEnvOptions(opts ...sourceenv.Option) Option {
+	return func(d *Dotenv) { d.envOptions = append(d.envOptions, opts...) }
+}
@@ -0,0 +1,32 @@
+package sourcedotenv
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	sourceenv "github.com/krostar/config/source/env"
+)
+
+func Test_MayNotExist(t *testing.T) {
+	var d = Dotenv{strictOpen: true}
+	MayNotExist()(&d)
+	assert.False(t, d.strictOpen)
+}
+
+func Test_WithFs(t *testing.T) {
+	var (
+		d  Dotenv
+		fs = afero.NewMemMapFs()
+	)
+	WithFs(fs)(&d)
+	assert.Equal(t, fs, d.fs)
+}
+
+func Test_WithEnvOptions(t *testing.T) {
+	var d Dotenv
+	WithEnvOptions(sourceenv.ReadStructsAsJSON())(&d)
+	WithEnvOptions(sourceenv.ReadFileSuffix())(&d)
+	assert.Len(t, d.envOptions, 2)
+}
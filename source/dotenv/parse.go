@@ -0,0 +1,252 @@
+package sourcedotenv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// parser parses dotenv content, one variable at a time.
+type parser struct {
+	content string
+	pos     int
+	line    int
+
+	vars map[string]string
+	// lookup is used to expand variables that are not defined in the content.
+	lookup func(key string) (string, bool)
+}
+
+// parse parses dotenv content (see the package documentation for the syntax).
+// Variables that are not defined in the content are expanded with lookup.
+func parse(content string, lookup func(key string) (string, bool)) (map[string]string, error) {
+	var p = parser{
+		content: strings.NewReplacer("\r\n", "\n").Replace(content),
+		line:    1,
+		vars:    make(map[string]string),
+		lookup:  lookup,
+	}
+
+	for {
+		p.skip(" \t\n")
+		if p.eof() {
+			return p.vars, nil
+		}
+
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+
+		if err := p.parseVariable(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", p.line, err)
+		}
+	}
+}
+
+func (p *parser) eof() bool { return p.pos >= len(p.content) }
+
+func (p *parser) peek() byte { return p.content[p.pos] }
+
+func (p *parser) next() byte {
+	c := p.content[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+func (p *parser) skip(chars string) {
+	for !p.eof() && strings.IndexByte(chars, p.peek()) >= 0 {
+		p.next()
+	}
+}
+
+func (p *parser) skipLine() {
+	for !p.eof() && p.peek() != '\n' {
+		p.next()
+	}
+}
+
+func (p *parser) parseVariable() error {
+	key := p.parseKey()
+	if key == "export" && !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.skip(" \t")
+		key = p.parseKey()
+	}
+	if key == "" {
+		return errors.New("expected a variable name")
+	}
+
+	p.skip(" \t")
+	if p.eof() || p.peek() != '=' {
+		return fmt.Errorf("expected = after variable %s", key)
+	}
+	p.next()
+	p.skip(" \t")
+
+	var (
+		value string
+		err   error
+	)
+
+	switch {
+	case p.eof():
+	case p.peek() == '\'':
+		value, err = p.parseSingleQuoted()
+	case p.peek() == '"':
+		value, err = p.parseDoubleQuoted()
+	default:
+		value, err = p.parseUnquoted()
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value of variable %s: %w", key, err)
+	}
+
+	// only comments can follow quoted values
+	p.skip(" \t")
+	if !p.eof() && p.peek() != '\n' && p.peek() != '#' {
+		return fmt.Errorf("unexpected %q after value of variable %s", p.peek(), key)
+	}
+	p.skipLine()
+
+	p.vars[key] = value
+	return nil
+}
+
+func (p *parser) parseKey() string {
+	var start = p.pos
+	for !p.eof() && isKeyChar(p.peek()) {
+		p.next()
+	}
+	return p.content[start:p.pos]
+}
+
+func (p *parser) parseSingleQuoted() (string, error) {
+	p.next()
+
+	var start = p.pos
+	for !p.eof() && p.peek() != '\'' {
+		p.next()
+	}
+	if p.eof() {
+		return "", errors.New("unterminated single quoted value")
+	}
+
+	value := p.content[start:p.pos]
+	p.next()
+
+	return value, nil
+}
+
+func (p *parser) parseDoubleQuoted() (string, error) {
+	p.next()
+
+	var value strings.Builder
+
+	for !p.eof() && p.peek() != '"' {
+		switch c := p.next(); c {
+		case '\\':
+			if p.eof() {
+				return "", errors.New("unterminated double quoted value")
+			}
+			switch escaped := p.next(); escaped {
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			case '"', '\\', '$':
+				value.WriteByte(escaped)
+			default:
+				value.WriteByte('\\')
+				value.WriteByte(escaped)
+			}
+		case '$':
+			expanded, err := p.expandVariable()
+			if err != nil {
+				return "", err
+			}
+			value.WriteString(expanded)
+		default:
+			value.WriteByte(c)
+		}
+	}
+
+	if p.eof() {
+		return "", errors.New("unterminated double quoted value")
+	}
+	p.next()
+
+	return value.String(), nil
+}
+
+func (p *parser) parseUnquoted() (string, error) {
+	var value strings.Builder
+
+	for !p.eof() && p.peek() != '\n' {
+		// comments start with a # preceded by a space
+		if p.peek() == '#' && p.pos > 0 && (p.content[p.pos-1] == ' ' || p.content[p.pos-1] == '\t') {
+			break
+		}
+
+		if c := p.next(); c == '$' {
+			expanded, err := p.expandVariable()
+			if err != nil {
+				return "", err
+			}
+			value.WriteString(expanded)
+		} else {
+			value.WriteByte(c)
+		}
+	}
+
+	return strings.TrimSpace(value.String()), nil
+}
+
+// expandVariable expands the variable that follows a $, either ${VAR} or $VAR.
+// A $ that is not followed by a variable name is kept as is.
+func (p *parser) expandVariable() (string, error) {
+	var name string
+
+	if !p.eof() && p.peek() == '{' {
+		p.next()
+
+		var start = p.pos
+		for !p.eof() && p.peek() != '}' && p.peek() != '\n' {
+			p.next()
+		}
+		if p.eof() || p.peek() != '}' {
+			return "", fmt.Errorf("unterminated variable expansion ${%s", p.content[start:p.pos])
+		}
+		name = p.content[start:p.pos]
+		p.next()
+	} else {
+		var start = p.pos
+		for !p.eof() && isKeyChar(p.peek()) && p.peek() != '.' {
+			p.next()
+		}
+		name = p.content[start:p.pos]
+		if name == "" {
+			return "$", nil
+		}
+	}
+
+	if value, exists := p.vars[name]; exists {
+		return value, nil
+	}
+	if p.lookup != nil {
+		if value, exists := p.lookup(name); exists {
+			return value, nil
+		}
+	}
+
+	return "", nil
+}
+
+func isKeyChar(c byte) bool {
+	return c == '_' || c == '.' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,99 @@
+package sourcedotenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parse(t *testing.T) {
+	var tests = map[string]struct {
+		content         string
+		expected        map[string]string
+		expectedFailure string
+	}{
+		"empty": {
+			content:  "",
+			expected: map[string]string{},
+		}, "comments and empty lines": {
+			content:  "# comment\n\n  # indented comment\r\nA=a\n",
+			expected: map[string]string{"A": "a"},
+		}, "missing equal sign": {
+			content:         "A=a\nB",
+			expectedFailure: "line 2: expected = after variable B",
+		}, "unquoted values": {
+			content: "A = a value  \nB=b#not a comment\nC=c # comment\nD=\nE=",
+			expected: map[string]string{
+				"A": "a value",
+				"B": "b#not a comment",
+				"C": "c",
+				"D": "",
+				"E": "",
+			},
+		}, "export prefix": {
+			content:  "export A=a\nexport\tB=b\nexport=c",
+			expected: map[string]string{"A": "a", "B": "b", "export": "c"},
+		}, "single quoted": {
+			content:  "A='a $B \\n \"b\"' # comment\nB='multi\nline'",
+			expected: map[string]string{"A": `a $B \n "b"`, "B": "multi\nline"},
+		}, "double quoted": {
+			content:  `A="a\n\t\"b\" \\ \$B \c"` + "\nB=\"multi\nline\"",
+			expected: map[string]string{"A": "a\n\t\"b\" \\ $B \\c", "B": "multi\nline"},
+		}, "expansion": {
+			content: "A=a\nB=${A}b\nC=\"$A.$B c\"\nD='$A'\nE=$UNDEFINED\nF=$FROMENV\nG=$ $\nA=redefined",
+			expected: map[string]string{
+				"A": "redefined",
+				"B": "ab",
+				"C": "a.ab c",
+				"D": "$A",
+				"E": "",
+				"F": "env",
+				"G": "$ $",
+			},
+		}, "missing variable name": {
+			content:         "A=a\n=b",
+			expectedFailure: "line 2: expected a variable name",
+		}, "missing variable name after export": {
+			content:         "export ",
+			expectedFailure: "line 1: expected a variable name",
+		}, "unterminated single quote": {
+			content:         "A='a\nb",
+			expectedFailure: "line 2: invalid value of variable A: unterminated single quoted value",
+		}, "unterminated double quote": {
+			content:         `A="a\`,
+			expectedFailure: "line 1: invalid value of variable A: unterminated double quoted value",
+		}, "unterminated expansion": {
+			content:         "A=${B\nC=c",
+			expectedFailure: "line 1: invalid value of variable A: unterminated variable expansion ${B",
+		}, "unterminated expansion in double quotes": {
+			content:         `A="${B"`,
+			expectedFailure: `line 1: invalid value of variable A: unterminated variable expansion ${B"`,
+		}, "content after quoted value": {
+			content:         `A="a" b`,
+			expectedFailure: `line 1: unexpected 'b' after value of variable A`,
+		},
+	}
+
+	var lookup = func(key string) (string, bool) {
+		if key == "FROMENV" {
+			return "env", true
+		}
+		return "", false
+	}
+
+	for name, test := range tests {
+		var test = test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			vars, err := parse(test.content, lookup)
+			if test.expectedFailure != "" {
+				require.EqualError(t, err, test.expectedFailure)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, vars)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package sourceenv_test
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/krostar/config"
+	"github.com/krostar/config/configtest"
+	sourceenv "github.com/krostar/config/source/env"
+)
+
+func TestEnv_conformance(t *testing.T) {
+	var suites int32
+
+	configtest.RunSourceSuite(t, func(t *testing.T, values map[string]interface{}) config.Source {
+		// each sub tests uses its own prefix to not see others env vars
+		prefix := fmt.Sprintf("conformance%d", atomic.AddInt32(&suites, 1))
+
+		for treePath, value := range configtest.Flatten(values) {
+			key := strings.ToUpper(prefix + "_" + strings.ReplaceAll(treePath, ".", "_"))
+			configtest.Setenv(t, key, value)
+		}
+
+		source, err := sourceenv.New(prefix)()
+		require.NoError(t, err)
+		return source
+	})
+}
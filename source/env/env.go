@@ -2,25 +2,47 @@
 package sourceenv
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"reflect"
 	"strings"
 
+	"github.com/spf13/afero"
+
 	"github.com/krostar/config"
-	"github.com/krostar/config/internal/trivialerr"
+	"github.com/krostar/config/trivialerr"
 )
 
 // Env implements config.Source to fetch values from env
 // based on the value's key.
 type Env struct {
 	prefix string
+
+	structsAsJSON bool
+	fileSuffix    bool
+	fs            afero.Fs
+
+	lookupEnv func(key string) (string, bool)
+	environ   func() []string
 }
 
 // New returns a new env source.
-func New(prefix string) config.SourceCreationFunc {
+func New(prefix string, opts ...Option) config.SourceCreationFunc {
 	return func() (config.Source, error) {
-		return &Env{prefix: prefix}, nil
+		e := Env{
+			prefix: prefix,
+			fs:     afero.NewReadOnlyFs(afero.NewOsFs()),
+
+			lookupEnv: os.LookupEnv,
+			environ:   os.Environ,
+		}
+
+		for _, opt := range opts {
+			opt(&e)
+		}
+
+		return &e, nil
 	}
 }
 
@@ -34,20 +56,115 @@ func (e *Env) keyFormatter(key string) string {
 		Replace(strings.ToUpper(e.prefix + "_" + key))
 }
 
+// fileSuffix is the suffix of variables that contain the path of a
+// file to read the value from, when the ReadFileSuffix option is used.
+const fileSuffix = "_FILE"
+
+// DescribeKey implements config.SourceKeyDescriber interface.
+func (e *Env) DescribeKey(treePath string) string {
+	key := e.keyFormatter(treePath)
+	if e.fileSuffix {
+		return key + " or " + key + fileSuffix
+	}
+	return key
+}
+
+// SetsStructValue implements config.SourceSetStructValue interface.
+// It returns true if the ReadStructsAsJSON option is used.
+func (e *Env) SetsStructValue() bool { return e.structsAsJSON }
+
+// ListKeys implements config.SourceKeyLister interface. It returns, lowercased, the
+// part that directly follows the key of the tree path in any variable of the system
+// environment: given PREFIX_SERVERS_0_HOST and PREFIX_SERVERS_1_HOST, ListKeys("servers")
+// returns "0" and "1". As underscores separate parts, map keys can't contain one.
+func (e *Env) ListKeys(treePath string) ([]string, error) {
+	var (
+		key   = e.keyFormatter(treePath)
+		names []string
+	)
+
+	for _, env := range e.environ() {
+		name := strings.SplitN(env, "=", 2)[0]
+
+		// the variable that points to a file for the tree path itself is not a key
+		if !strings.HasPrefix(name, key+"_") || (e.fileSuffix && name == key+fileSuffix) {
+			continue
+		}
+
+		// keys are lowercased, like tree paths
+		names = append(names, strings.ToLower(name))
+	}
+
+	return config.ListKeysOfPaths(strings.ToLower(key), "_", names), nil
+}
+
 // SetValueFromConfigTreePath gets the key's value from the system environment and
 // set it. It return an error that implement IsTrivial when the key is not found.
 func (e *Env) SetValueFromConfigTreePath(v *reflect.Value, treePath string) (bool, error) {
+	return e.SetValueFromConfigTreePathContext(context.Background(), v, treePath)
+}
+
+// SetValueFromConfigTreePathContext implements config.SourceSetValueFromConfigTreePathContext.
+// Slices, arrays and maps are read from separator-delimited values (`a,b,c`, `k1=v1,k2=v2`),
+// separators can be escaped with a backslash and changed with the `sep` and `kvsep` tags.
+func (e *Env) SetValueFromConfigTreePathContext(ctx context.Context, v *reflect.Value, treePath string) (bool, error) {
 	treePath = e.keyFormatter(treePath)
 
-	env, exists := os.LookupEnv(treePath)
-	if !exists {
-		return false, trivialerr.New("env does not contain key %s", treePath)
+	env, file, err := e.lookup(treePath)
+	if err != nil {
+		return false, err
+	}
+
+	var newV *reflect.Value
+
+	if config.IsDelimitedType(v.Type()) {
+		field, _ := config.StructFieldFromContext(ctx)
+		sep, kvsep := config.DelimitersOfStructField(field)
+		newV, err = config.InitializeNewValueOfTypeWithDelimitedString(v.Type(), env, sep, kvsep)
+	} else if v.Kind() == reflect.Struct && !config.HasStringDecoder(v.Type()) {
+		// structs read as json are decoded onto their current value
+		newV, err = config.InitializeNewValueOfStructWithJSON(*v, []byte(env))
+	} else {
+		newV, err = config.InitializeNewValueOfTypeWithString(v.Type(), env)
 	}
 
-	newV, err := config.InitializeNewValueOfTypeWithString(v.Type(), env)
 	if err != nil {
+		// files usually contain secrets, their content must not be leaked
+		if file != "" {
+			return false, fmt.Errorf(
+				"unable to initialize new value from file %s of %s%s: %w",
+				file, treePath, fileSuffix, config.RedactDecodeError(err),
+			)
+		}
 		return false, fmt.Errorf("unable to initialize new value from %q: %w", env, err)
 	}
 
 	return config.SetNewValue(v, newV)
 }
+
+// lookup returns the value of the env key or, if the ReadFileSuffix option
+// is used and only key_FILE is defined, the content of the file it points to,
+// without its trailing newline if any. In that case file is the path of the file.
+func (e *Env) lookup(key string) (value string, file string, err error) {
+	value, exists := e.lookupEnv(key)
+
+	if e.fileSuffix {
+		path, fileExists := e.lookupEnv(key + fileSuffix)
+		switch {
+		case exists && fileExists:
+			return "", "", fmt.Errorf("env contains both key %s and %s%s, only one can be set", key, key, fileSuffix)
+		case fileExists:
+			content, err := afero.ReadFile(e.fs, path)
+			if err != nil {
+				return "", "", fmt.Errorf("unable to read file of key %s%s: %w", key, fileSuffix, err)
+			}
+			return config.TrimTrailingNewline(string(content)), path, nil
+		}
+	}
+
+	if !exists {
+		return "", "", fmt.Errorf("env does not contain key %s: %w", key, trivialerr.ErrNotFound)
+	}
+
+	return value, "", nil
+}
@@ -1,18 +1,33 @@
 package sourceenv
 
 import (
+	"errors"
+	"net/url"
 	"os"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/krostar/config/internal/trivialerr"
+	"github.com/krostar/config"
+	"github.com/krostar/config/trivialerr"
 )
 
-func newEnv(t *testing.T, prefix string) *Env {
-	env, err := New(prefix)()
+type level string
+
+func (l *level) UnmarshalText(text []byte) error {
+	*l = level(strings.ToUpper(string(text)))
+	return nil
+}
+
+func newEnv(t *testing.T, prefix string, opts ...Option) *Env {
+	env, err := New(prefix, opts...)()
 	require.NoError(t, err)
 	return env.(*Env)
 }
@@ -45,6 +60,13 @@ func TestEnv_SetValueFromConfigTreePath(t *testing.T) {
 			expectedValue:          "hello",
 			expectedFailure:        false,
 			expectedTrivialFailure: false,
+		}, "test text unmarshaler": {
+			key:                    "level",
+			envKey:                 prefixUp + "_LEVEL",
+			envValue:               "warn",
+			expectedValue:          level("WARN"),
+			expectedFailure:        false,
+			expectedTrivialFailure: false,
 		}, "test not found": {
 			key:                    "willnobefound",
 			expectedValue:          "hello",
@@ -74,6 +96,7 @@ func TestEnv_SetValueFromConfigTreePath(t *testing.T) {
 				require.Error(t, err)
 				if test.expectedTrivialFailure {
 					require.True(t, trivialerr.IsTrivial(err))
+					require.True(t, errors.Is(err, trivialerr.ErrNotFound))
 				}
 			} else {
 				require.NoError(t, err)
@@ -84,10 +107,154 @@ func TestEnv_SetValueFromConfigTreePath(t *testing.T) {
 	}
 }
 
+func TestEnv_SetValueFromConfigTreePathContext_delimited(t *testing.T) {
+	type cfg struct {
+		Hosts   []string
+		Ports   []int          `sep:";"`
+		Labels  map[string]int `sep:";" kvsep:":"`
+		Invalid []int
+	}
+
+	for key, value := range map[string]string{
+		"PREFIXDELIM_HOSTS":  "a,b,c",
+		"PREFIXDELIM_PORTS":  "80;443",
+		"PREFIXDELIM_LABELS": "a:1;b:2",
+	} {
+		require.NoError(t, os.Setenv(key, value))
+		defer os.Unsetenv(key) // nolint: errcheck
+	}
+
+	var c cfg
+	require.NoError(t, config.Load(&c, config.WithSources(New("prefixdelim"))))
+	assert.Equal(t, cfg{
+		Hosts:  []string{"a", "b", "c"},
+		Ports:  []int{80, 443},
+		Labels: map[string]int{"a": 1, "b": 2},
+	}, c)
+
+	require.NoError(t, os.Setenv("PREFIXDELIM_INVALID", "1;2"))
+	defer os.Unsetenv("PREFIXDELIM_INVALID") // nolint: errcheck
+
+	require.Error(t, config.Load(&c, config.WithSources(New("prefixdelim"))))
+}
+
+func TestEnv_ListKeys(t *testing.T) {
+	type (
+		server struct {
+			Host string
+		}
+		tenant struct {
+			DSN string
+		}
+		cfg struct {
+			Servers []server
+			Tenants map[string]*tenant
+		}
+	)
+
+	for key, value := range map[string]string{
+		"PREFIXKEYS_SERVERS_0_HOST":   "a",
+		"PREFIXKEYS_SERVERS_1_HOST":   "b",
+		"PREFIXKEYS_TENANTS_ACME_DSN": "acme",
+	} {
+		require.NoError(t, os.Setenv(key, value))
+		defer os.Unsetenv(key) // nolint: errcheck
+	}
+
+	env := newEnv(t, "prefixkeys")
+
+	keys, err := env.ListKeys("servers")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "1"}, keys)
+
+	keys, err = env.ListKeys("nothing")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	var c cfg
+	require.NoError(t, config.Load(&c, config.WithRawSources(env)))
+	assert.Equal(t, cfg{
+		Servers: []server{{Host: "a"}, {Host: "b"}},
+		Tenants: map[string]*tenant{"acme": {DSN: "acme"}},
+	}, c)
+}
+
+func TestEnv_SetsStructValue(t *testing.T) {
+	type (
+		database struct {
+			Host string
+			Port int
+		}
+		server struct {
+			Host string
+		}
+		cfg struct {
+			Database  database
+			Servers   []server
+			Untouched *database
+		}
+	)
+
+	for key, value := range map[string]string{
+		"PREFIXJSON_DATABASE":      `{"host": "db", "port": 5432}`,
+		"PREFIXJSON_DATABASE_PORT": "5433",
+		"PREFIXJSON_SERVERS":       `[{"host": "a"}, {"host": "b"}]`,
+	} {
+		require.NoError(t, os.Setenv(key, value))
+		defer os.Unsetenv(key) // nolint: errcheck
+	}
+
+	var c cfg
+	require.NoError(t, config.Load(&c, config.WithSources(New("prefixjson"))))
+	assert.Equal(t, cfg{
+		Database: database{Port: 5433},
+		Servers:  []server{{Host: "a"}, {Host: "b"}},
+	}, c)
+
+	c = cfg{}
+	require.NoError(t, config.Load(&c, config.WithSources(New("prefixjson", ReadStructsAsJSON()))))
+	assert.Equal(t, cfg{
+		Database: database{Host: "db", Port: 5433},
+		Servers:  []server{{Host: "a"}, {Host: "b"}},
+	}, c)
+
+	require.NoError(t, os.Setenv("PREFIXJSON_DATABASE", `{"port": "not a number"}`))
+	require.Error(t, config.Load(&c, config.WithSources(New("prefixjson", ReadStructsAsJSON()))))
+}
+
+func Test_LoadWithReport_structsAsJSON(t *testing.T) {
+	type (
+		database struct {
+			Host string
+			Port int `default:"5432"`
+		}
+		cfg struct {
+			Database database
+		}
+	)
+
+	var c cfg
+
+	// fields absent from the json value keep their current value
+	report, err := config.LoadWithReport(&c, config.WithSources(New("rv", ReadStructsAsJSON(), FromMap(map[string]string{
+		"RV_DATABASE": `{"host": "db"}`,
+	}))))
+	require.NoError(t, err)
+	assert.Equal(t, cfg{Database: database{Host: "db", Port: 5432}}, c)
+	assert.Equal(t, config.LoadReport{
+		"database.host": "env",
+		"database.port": config.LoadReportDefault,
+	}, report)
+}
+
 func TestEnv_Name(t *testing.T) {
 	require.Equal(t, "env", newEnv(t, "").Name())
 }
 
+func TestEnv_DescribeKey(t *testing.T) {
+	require.Equal(t, "PREFIX_KEY_NAME", newEnv(t, "prefix").DescribeKey("key.name"))
+}
+
 func TestEnv_keyFormatter(t *testing.T) {
 	tests := map[string]struct {
 		keyName              string
@@ -125,3 +292,153 @@ func TestEnv_keyFormatter(t *testing.T) {
 		})
 	}
 }
+
+func TestEnv_ReadFileSuffix(t *testing.T) {
+	var fs = afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/run/secrets/password", []byte("secret\n"), 0600))
+	require.NoError(t, afero.WriteFile(fs, "/run/secrets/port", []byte("not a number"), 0600))
+
+	var vars = map[string]string{
+		"APP_PASSWORD_FILE": "/run/secrets/password",
+		"APP_PORT_FILE":     "/run/secrets/port",
+		"APP_MISSING_FILE":  "/run/secrets/missing",
+		"APP_BOTH":          "value",
+		"APP_BOTH_FILE":     "/run/secrets/password",
+		"APP_HOSTS_FILE":    "/run/secrets/password",
+		"APP_HOSTS_0":       "a",
+	}
+
+	var (
+		withSuffix    = newEnv(t, "app", FromMap(vars), ReadFileSuffix(), WithFs(fs))
+		withoutSuffix = newEnv(t, "app", FromMap(vars), WithFs(fs))
+		str           = reflect.New(reflect.TypeOf("")).Elem()
+		integer       = reflect.New(reflect.TypeOf(0)).Elem()
+	)
+
+	isSet, err := withSuffix.SetValueFromConfigTreePath(&str, "password")
+	require.NoError(t, err)
+	assert.True(t, isSet)
+	assert.Equal(t, "secret", str.Interface())
+
+	_, err = withoutSuffix.SetValueFromConfigTreePath(&str, "password")
+	require.Error(t, err)
+	assert.True(t, trivialerr.IsTrivial(err))
+
+	_, err = withSuffix.SetValueFromConfigTreePath(&str, "both")
+	require.EqualError(t, err, "env contains both key APP_BOTH and APP_BOTH_FILE, only one can be set")
+	assert.False(t, trivialerr.IsTrivial(err))
+
+	isSet, err = withoutSuffix.SetValueFromConfigTreePath(&str, "both")
+	require.NoError(t, err)
+	assert.True(t, isSet)
+	assert.Equal(t, "value", str.Interface())
+
+	_, err = withSuffix.SetValueFromConfigTreePath(&str, "missing")
+	require.Error(t, err)
+	assert.False(t, trivialerr.IsTrivial(err))
+
+	_, err = withSuffix.SetValueFromConfigTreePath(&str, "unknown")
+	require.Error(t, err)
+	assert.True(t, trivialerr.IsTrivial(err))
+
+	_, err = withSuffix.SetValueFromConfigTreePath(&integer, "port")
+	require.EqualError(t, err,
+		"unable to initialize new value from file /run/secrets/port of APP_PORT_FILE: unable to decode redacted value to int",
+	)
+	var decodeErr *config.DecodeError
+	require.True(t, errors.As(err, &decodeErr), "the cause should be kept")
+	assert.Empty(t, decodeErr.RawValue)
+
+	keys, err := withSuffix.ListKeys("hosts")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0"}, keys)
+	keys, err = withoutSuffix.ListKeys("hosts")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "file"}, keys)
+
+	assert.Equal(t, "APP_PASSWORD or APP_PASSWORD_FILE", withSuffix.DescribeKey("password"))
+	assert.Equal(t, "APP_PASSWORD", withoutSuffix.DescribeKey("password"))
+}
+
+func Test_Load_builtinStructDecoders(t *testing.T) {
+	// structs with a decoder must be decoded as a whole, not walked through
+	var cfg struct {
+		URL url.URL
+		At  time.Time
+	}
+
+	require.NoError(t, config.Load(&cfg, config.WithSources(New("app", FromMap(map[string]string{
+		"APP_URL": "https://example.com/path",
+		"APP_AT":  "2020-05-04T10:00:00Z",
+	})))))
+	assert.Equal(t, url.URL{Scheme: "https", Host: "example.com", Path: "/path"}, cfg.URL)
+	assert.Equal(t, time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC), cfg.At)
+}
+
+// hostPort is a struct kind type that decodes itself from text.
+type hostPort struct {
+	Host string
+	Port int
+}
+
+func (hp *hostPort) UnmarshalText(text []byte) error {
+	parts := strings.SplitN(string(text), ":", 2)
+	if len(parts) != 2 {
+		return errors.New("missing port")
+	}
+
+	port, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return err
+	}
+
+	*hp = hostPort{Host: parts[0], Port: port}
+	return nil
+}
+
+func Test_Load_structTextUnmarshaler(t *testing.T) {
+	var cfg struct {
+		Addr    hostPort
+		PtrAddr *hostPort
+	}
+
+	require.NoError(t, config.Load(&cfg, config.WithSources(New("app", FromMap(map[string]string{
+		"APP_ADDR":    "localhost:8080",
+		"APP_PTRADDR": "db:5432",
+	})))))
+	assert.Equal(t, hostPort{Host: "localhost", Port: 8080}, cfg.Addr)
+	assert.Equal(t, &hostPort{Host: "db", Port: 5432}, cfg.PtrAddr)
+
+	require.Error(t, config.Load(&cfg, config.WithSources(New("app", FromMap(map[string]string{
+		"APP_ADDR": "localhost",
+	})))))
+}
+
+// upper is a struct kind type with a decoder registered for its pointer.
+type upper struct {
+	Value string
+}
+
+func Test_LoadWithReport_pointerDecoders(t *testing.T) {
+	config.RegisterDecoder(reflect.TypeOf(&upper{}), func(str string) (interface{}, error) {
+		return &upper{Value: strings.ToUpper(str)}, nil
+	})
+
+	var cfg struct {
+		R  *regexp.Regexp
+		U  *upper
+		UV upper
+	}
+
+	report, err := config.LoadWithReport(&cfg, config.WithSources(New("p", FromMap(map[string]string{
+		"P_R":  "^a+$",
+		"P_U":  "hello",
+		"P_UV": "world",
+	}))))
+	require.NoError(t, err)
+	require.NotNil(t, cfg.R)
+	assert.Equal(t, "^a+$", cfg.R.String())
+	assert.Equal(t, &upper{Value: "HELLO"}, cfg.U)
+	assert.Equal(t, upper{Value: "WORLD"}, cfg.UV)
+	assert.Equal(t, config.LoadReport{"r": "env", "u": "env", "uv": "env"}, report)
+}
@@ -0,0 +1,52 @@
+package sourceenv
+
+import (
+	"sort"
+
+	"github.com/spf13/afero"
+)
+
+// Option defines the function signature to apply options.
+type Option func(e *Env)
+
+// ReadStructsAsJSON tells the env source to also look for structs as a whole,
+// for example PREFIX_DATABASE='{"host": "db", "port": 5432}' for a Database struct
+// field, decoded as json onto the current value of the struct, so fields absent
+// from the json keep their defaults. Variables of each fields are still looked
+// up, and override what is defined in the json.
+func ReadStructsAsJSON() Option {
+	return func(e *Env) { e.structsAsJSON = true }
+}
+
+// FromMap tells the env source to read variables from the provided
+// map, indexed by variable names, instead of the process environment.
+func FromMap(vars map[string]string) Option {
+	return func(e *Env) {
+		e.lookupEnv = func(key string) (string, bool) {
+			value, exists := vars[key]
+			return value, exists
+		}
+		e.environ = func() []string {
+			var environ = make([]string, 0, len(vars))
+			for key, value := range vars {
+				environ = append(environ, key+"="+value)
+			}
+			sort.Strings(environ)
+			return environ
+		}
+	}
+}
+
+// ReadFileSuffix tells the env source to read the value of a key from the file
+// pointed by the key suffixed by _FILE, like PREFIX_PASSWORD_FILE=/run/secrets/password,
+// when the key itself is not defined. Defining both is an error. The trailing
+// newline of the file, if any, is removed.
+func ReadFileSuffix() Option {
+	return func(e *Env) { e.fileSuffix = true }
+}
+
+// WithFs sets the filesystem files are read from (see ReadFileSuffix),
+// which is the read-only os filesystem by default.
+func WithFs(fs afero.Fs) Option {
+	return func(e *Env) { e.fs = fs }
+}
@@ -0,0 +1,50 @@
+package sourceenv
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReadStructsAsJSON(t *testing.T) {
+	e := newEnv(t, "")
+	assert.False(t, e.structsAsJSON)
+	ReadStructsAsJSON()(e)
+	assert.True(t, e.structsAsJSON)
+}
+
+func Test_FromMap(t *testing.T) {
+	e := newEnv(t, "prefix", FromMap(map[string]string{
+		"PREFIX_SERVERS_1": "b",
+		"PREFIX_SERVERS_0": "a",
+	}))
+
+	value, exists := e.lookupEnv("PREFIX_SERVERS_0")
+	assert.True(t, exists)
+	assert.Equal(t, "a", value)
+	_, exists = e.lookupEnv("PATH")
+	assert.False(t, exists)
+	assert.Equal(t, []string{"PREFIX_SERVERS_0=a", "PREFIX_SERVERS_1=b"}, e.environ())
+
+	keys, err := e.ListKeys("servers")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "1"}, keys)
+}
+
+func Test_ReadFileSuffix(t *testing.T) {
+	e := newEnv(t, "")
+	assert.False(t, e.fileSuffix)
+	ReadFileSuffix()(e)
+	assert.True(t, e.fileSuffix)
+}
+
+func Test_WithFs(t *testing.T) {
+	var fs = afero.NewMemMapFs()
+
+	e := newEnv(t, "")
+	assert.NotNil(t, e.fs)
+	WithFs(fs)(e)
+	assert.Equal(t, fs, e.fs)
+}
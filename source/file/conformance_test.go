@@ -0,0 +1,27 @@
+package sourcefile_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/krostar/config"
+	"github.com/krostar/config/configtest"
+	sourcefile "github.com/krostar/config/source/file"
+)
+
+func TestFile_conformance(t *testing.T) {
+	configtest.RunSourceSuite(t, func(t *testing.T, values map[string]interface{}) config.Source {
+		raw, err := yaml.Marshal(values)
+		require.NoError(t, err)
+
+		fs := afero.NewMemMapFs()
+		configtest.WriteFile(t, fs, "config.yaml", string(raw))
+
+		source, err := sourcefile.New("config.yaml", sourcefile.WithFs(fs))()
+		require.NoError(t, err)
+		return source
+	})
+}
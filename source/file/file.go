@@ -2,28 +2,43 @@
 package sourcefile
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/krostar/config"
 
 	"github.com/spf13/afero"
-	yaml "gopkg.in/yaml.v3"
 
-	"github.com/krostar/config/internal/trivialerr"
+	"github.com/krostar/config/trivialerr"
 )
 
 // File implements config.Source to fetch values from a file.
 type File struct {
 	fs afero.Fs
 
-	path string
-	ext  string
+	path   string
+	ext    string
+	format string
 
 	strictUnmarshal bool
 	strictOpen      bool
+
+	interpolate         bool
+	strictInterpolation bool
+	lookupEnv           func(key string) (string, bool)
+
+	watchInterval time.Duration
+
+	// loaded is the state of the file when it was last unmarshaled
+	loadedMutex sync.Mutex
+	loaded      *fileState
 }
 
 // New returns a new file source.
@@ -45,6 +60,11 @@ func New(path string, opts ...Option) config.SourceCreationFunc {
 
 			strictUnmarshal: false,
 			strictOpen:      true,
+
+			interpolate: true,
+			lookupEnv:   os.LookupEnv,
+
+			watchInterval: 5 * time.Second,
 		}
 
 		for _, opt := range opts {
@@ -58,30 +78,47 @@ func New(path string, opts ...Option) config.SourceCreationFunc {
 // Name implements config.Source interface.
 func (f *File) Name() string { return "file" }
 
-// Unmarshal tries to unmarshal file to the provided interface.
-// It returns a trivial error if load strictness is false, or the true error otherwise.
+// DescribeKey implements config.SourceKeyDescriber interface. Keys of the file
+// depend on its format and on the struct tags, which are not known from the
+// tree path, so the tree path is labeled as such.
+func (f *File) DescribeKey(treePath string) string {
+	return f.path + " (tree path " + treePath + ")"
+}
+
+// Unmarshal tries to unmarshal file to the provided interface, once its variables, like
+// ${DB_HOST}, are replaced by their value (see WithoutInterpolation). The format of the
+// file is the one set with WithFormat, or the one registered for the file extension
+// (see RegisterFormat). Otherwise, registered formats are tried until one is able to
+// decode the file. It returns a trivial error if load strictness is false, or the true
+// error otherwise.
 func (f *File) Unmarshal(to interface{}) error {
+	// the state is taken before reading, to not miss changes made meanwhile
+	state := f.state()
+	f.loadedMutex.Lock()
+	f.loaded = &state
+	f.loadedMutex.Unlock()
+
 	ff, err := f.fs.Open(f.path)
 	if err != nil {
 		return trivialerr.WrapIf(f.strictOpen, fmt.Errorf("unable to open file: %w", err))
 	}
 	defer ff.Close() // nolint: errcheck, gosec
 
-	switch f.ext {
-	case "json":
-		var decoder = json.NewDecoder(ff)
-		if f.strictUnmarshal {
-			decoder.DisallowUnknownFields()
-		}
-		err = decoder.Decode(to)
-	case "yaml":
-		var decoder = yaml.NewDecoder(ff)
-		if f.strictUnmarshal {
-			decoder.KnownFields(true)
+	raw, err := ioutil.ReadAll(ff)
+	if err != nil {
+		return fmt.Errorf("unable to read file %q: %w", f.path, err)
+	}
+
+	var content = string(raw)
+	if f.interpolate {
+		if content, err = interpolate(content, f.formatName(), f.lookupEnv, f.strictInterpolation); err != nil {
+			return fmt.Errorf("unable to interpolate file %q: %w", f.path, err)
 		}
-		err = decoder.Decode(to)
-	default:
-		err = fmt.Errorf("%q extension is not supported", f.ext)
+	}
+
+	factory, err := f.decoderFactory(content, to)
+	if err == nil {
+		err = factory(strings.NewReader(content), f.strictUnmarshal).Decode(to)
 	}
 
 	if err != nil {
@@ -90,3 +127,96 @@ func (f *File) Unmarshal(to interface{}) error {
 
 	return nil
 }
+
+// formatName returns the name of the format of the file, if it is known
+// without reading it.
+func (f *File) formatName() string {
+	if f.format != "" {
+		return f.format
+	}
+	name, _ := lookupFormatByExtension(f.ext)
+	return name
+}
+
+func (f *File) decoderFactory(content string, to interface{}) (DecoderFactory, error) {
+	if f.format != "" {
+		factory, exists := lookupFormat(f.format)
+		if !exists {
+			return nil, fmt.Errorf("%q format is not supported", f.format)
+		}
+		return factory, nil
+	}
+
+	if name, exists := lookupFormatByExtension(f.ext); exists {
+		factory, _ := lookupFormat(name)
+		return factory, nil
+	}
+
+	// formats are tried on a new value to not alter the destination
+	var typ = reflect.TypeOf(to)
+	if typ == nil || typ.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("unable to detect the format of %q extension on a non-pointer", f.ext)
+	}
+
+	for _, name := range registeredFormats() {
+		factory, exists := lookupFormat(name)
+		if !exists {
+			continue
+		}
+		if err := factory(strings.NewReader(content), f.strictUnmarshal).Decode(reflect.New(typ.Elem()).Interface()); err == nil {
+			return factory, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%q extension is not supported, and no format is able to decode the file", f.ext)
+}
+
+// Watch implements config.SourceWatcher interface. It polls
+// the file and notifies each time its presence, its size or its
+// modification time changes, compared to when it was last
+// unmarshaled if it was. It only returns once ctx is done.
+func (f *File) Watch(ctx context.Context, notify func()) error {
+	var (
+		ticker = time.NewTicker(f.watchInterval)
+		state  = f.state()
+	)
+
+	defer ticker.Stop()
+
+	f.loadedMutex.Lock()
+	if f.loaded != nil {
+		state = *f.loaded
+	}
+	f.loadedMutex.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if newState := f.state(); newState != state {
+				state = newState
+				notify()
+			}
+		}
+	}
+}
+
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func (f *File) state() fileState {
+	info, err := f.fs.Stat(f.path)
+	if err != nil {
+		return fileState{}
+	}
+
+	return fileState{
+		exists:  true,
+		size:    info.Size(),
+		modTime: info.ModTime(),
+	}
+}
@@ -1,13 +1,17 @@
 package sourcefile
 
 import (
+	"context"
+	"io/ioutil"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/krostar/config/internal/trivialerr"
+	"github.com/krostar/config/trivialerr"
 )
 
 func newFile(t *testing.T, path string, opts ...Option) *File {
@@ -31,6 +35,10 @@ func Test_New(t *testing.T) {
 			path:              "path.yaml",
 			expectedPath:      "path.yaml",
 			expectedExtension: "yaml",
+		}, "toml file": {
+			path:              "path.toml",
+			expectedPath:      "path.toml",
+			expectedExtension: "toml",
 		}, "yml file": {
 			path:              "path.yml",
 			expectedPath:      "path.yml",
@@ -62,7 +70,7 @@ func Test_New(t *testing.T) {
 
 func TestFile_Unmarshal(t *testing.T) {
 	type helloWorld struct {
-		Hello string `json:"hello" yaml:"hello"`
+		Hello string `json:"hello" yaml:"hello" toml:"hello"`
 	}
 
 	var tests = map[string]struct {
@@ -86,8 +94,50 @@ func TestFile_Unmarshal(t *testing.T) {
 		}, "bli file": {
 			createFile:             true,
 			fileName:               "file.bli",
+			fileContent:            `{ not valid`,
 			expectedFailure:        true,
 			expectedTrivialFailure: false,
+		}, "unknown format": {
+			createFile:      true,
+			fileName:        "file.json",
+			fileContent:     `{"hello": "world"}`,
+			ffOpts:          []Option{WithFormat("bli")},
+			expectedFailure: true,
+		}, "explicit format": {
+			createFile:  true,
+			fileName:    "file.json",
+			fileContent: `hello: "world"`,
+			ffOpts:      []Option{WithFormat("yaml")},
+			expectedTo: helloWorld{
+				Hello: "world",
+			},
+		}, "sniffed json file": {
+			createFile:  true,
+			fileName:    "config",
+			fileContent: `{"hello": "world"}`,
+			expectedTo: helloWorld{
+				Hello: "world",
+			},
+		}, "sniffed toml file": {
+			createFile:  true,
+			fileName:    "config.conf",
+			fileContent: `hello = "world"`,
+			expectedTo: helloWorld{
+				Hello: "world",
+			},
+		}, "sniffed yaml file": {
+			createFile:  true,
+			fileName:    "config.conf",
+			fileContent: "# comment\nhello: world",
+			expectedTo: helloWorld{
+				Hello: "world",
+			},
+		}, "sniffed strict file": {
+			createFile:      true,
+			fileName:        "config.conf",
+			fileContent:     "hello: world\nworld: hello",
+			ffOpts:          []Option{FailOnUnknownFields()},
+			expectedFailure: true,
 		}, "json file": {
 			createFile:  true,
 			fileName:    "file.json",
@@ -114,6 +164,36 @@ func TestFile_Unmarshal(t *testing.T) {
 			fileContent:     `hell: "world"`,
 			ffOpts:          []Option{FailOnUnknownFields()},
 			expectedFailure: true,
+		}, "toml file": {
+			createFile:  true,
+			fileName:    "file.toml",
+			fileContent: `hello = "world"`,
+			expectedTo: helloWorld{
+				Hello: "world",
+			},
+		}, "toml file with unknown fields": {
+			createFile:  true,
+			fileName:    "file.toml",
+			fileContent: "hello = \"world\"\nworld = \"hello\"",
+			expectedTo: helloWorld{
+				Hello: "world",
+			},
+		}, "strict toml file": {
+			createFile:      true,
+			fileName:        "file.toml",
+			fileContent:     "hello = \"world\"\nworld = \"hello\"",
+			ffOpts:          []Option{FailOnUnknownFields()},
+			expectedFailure: true,
+		}, "invalid toml file": {
+			createFile:      true,
+			fileName:        "file.toml",
+			fileContent:     `hello = `,
+			expectedFailure: true,
+		}, "toml file cannot be open, with lazy opts": {
+			fileName:               "file.toml",
+			ffOpts:                 []Option{MayNotExist()},
+			expectedFailure:        true,
+			expectedTrivialFailure: true,
 		},
 	}
 
@@ -150,6 +230,140 @@ func TestFile_Unmarshal(t *testing.T) {
 	}
 }
 
+func TestFile_DescribeKey(t *testing.T) {
+	require.Equal(t, "file.yaml (tree path key.name)", newFile(t, "file.yaml").DescribeKey("key.name"))
+}
+
 func TestFile_Name(t *testing.T) {
 	require.Equal(t, "file", newFile(t, "").Name())
 }
+
+func TestFile_Watch(t *testing.T) {
+	// memory fs is not safe for concurrent stats and writes, use the real one
+	dir, err := ioutil.TempDir("", "sourcefile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	var (
+		fs      = afero.NewBasePathFs(afero.NewOsFs(), dir)
+		changes = make(chan struct{}, 10)
+		file    = newFile(t, "file.yaml", WatchInterval(time.Millisecond), MayNotExist(), func(f *File) { f.fs = fs })
+	)
+
+	// changes are detected from the last load, even those
+	// made before the watcher gets the state of the file
+	require.True(t, trivialerr.IsTrivial(file.Unmarshal(&map[string]string{})))
+	require.NoError(t, afero.WriteFile(fs, "file.yaml", []byte("hello: world"), 0600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- file.Watch(ctx, func() { changes <- struct{}{} })
+	}()
+
+	waitForChange := func() {
+		select {
+		case <-changes:
+		case <-time.After(time.Second):
+			t.Fatal("change has not been notified")
+		}
+	}
+
+	// creation
+	waitForChange()
+
+	// modification
+	require.NoError(t, afero.WriteFile(fs, "file.yaml", []byte("hello: world!"), 0600))
+	waitForChange()
+
+	// deletion
+	require.NoError(t, fs.Remove("file.yaml"))
+	waitForChange()
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestFile_Unmarshal_interpolation(t *testing.T) {
+	type database struct {
+		Host     string `json:"host" yaml:"host" toml:"host"`
+		Port     int    `json:"port" yaml:"port" toml:"port"`
+		Password string `json:"password" yaml:"password" toml:"password"`
+		Debug    bool   `json:"debug" yaml:"debug" toml:"debug"`
+	}
+
+	var fs = afero.NewMemMapFs()
+
+	for path, content := range map[string]string{
+		"quoted.yaml":   "host: ${DB_HOST:-localhost}\nport: ${DB_PORT}\npassword: \"${DB_PASSWORD}\"",
+		"unquoted.yaml": "host: ${DB_HOST:-localhost}\nport: ${DB_PORT}\npassword: ${DB_PASSWORD} # comment",
+		"quoted.json":   `{"host": "${DB_HOST:-localhost}", "port": ${DB_PORT}, "password": "${DB_PASSWORD}"}`,
+		"unquoted.json": `{"host": "${DB_HOST:-localhost}", "port": ${DB_PORT}, "password": ${DB_PASSWORD}}`,
+		"quoted.toml":   "host = \"${DB_HOST:-localhost}\"\nport = ${DB_PORT}\npassword = \"${DB_PASSWORD}\"",
+		"unquoted.toml": "host = \"${DB_HOST:-localhost}\"\nport = ${DB_PORT}\npassword = ${DB_PASSWORD}",
+		"strict.yaml":   "host: ${DB_HOST}",
+		"middle.yaml":   "password: secret-${DB_PASSWORD}",
+		"plain.yaml":    "host: ${DB_HOST:-db}-${DB_PORT}.local",
+		"block.yaml":    "host: localhost\nport: ${DB_PORT}\npassword: |-\n  ${DB_PASSWORD}",
+		"block.toml":    "host = \"localhost\"\nport = ${DB_PORT}\npassword = \"\"\"${DB_PASSWORD}\"\"\"",
+		"single.yaml":   "host: '${DB_HOST}'\npassword: '${DB_PASSWORD}'",
+		"single.toml":   "host = '${DB_HOST}'\npassword = '${DB_PASSWORD}'",
+	} {
+		require.NoError(t, afero.WriteFile(fs, path, []byte(content), 0600))
+	}
+
+	// values can't change the structure of the file, whatever their characters
+	for _, password := range []string{`p"a\s:s` + "\nw#o'rd", "ab #cd", "*anchor", "x\ndebug: true"} {
+		lookup := WithLookup(func(key string) (string, bool) {
+			value, exists := map[string]string{"DB_PORT": "5432", "DB_PASSWORD": password}[key]
+			return value, exists
+		})
+
+		for _, path := range []string{
+			"quoted.yaml", "unquoted.yaml", "quoted.json", "unquoted.json", "quoted.toml", "unquoted.toml", "block.yaml", "block.toml",
+		} {
+			var to database
+			require.NoError(t, newFile(t, path, WithFs(fs), lookup).Unmarshal(&to), path)
+			assert.Equal(t, database{Host: "localhost", Port: 5432, Password: password}, to, path)
+		}
+
+		err := newFile(t, "middle.yaml", WithFs(fs), lookup).Unmarshal(&database{})
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), password, "values must not be leaked")
+	}
+
+	var (
+		to     database
+		noVars = WithLookup(func(string) (string, bool) { return "", false })
+	)
+
+	// plain values can be used anywhere
+	require.NoError(t, newFile(t, "plain.yaml", WithFs(fs), WithLookup(func(key string) (string, bool) {
+		return "5432", key == "DB_PORT"
+	})).Unmarshal(&to))
+	assert.Equal(t, database{Host: "db-5432.local"}, to)
+
+	to = database{}
+	require.NoError(t, newFile(t, "middle.yaml", WithFs(fs), WithoutInterpolation()).Unmarshal(&to))
+	assert.Equal(t, database{Password: "secret-${DB_PASSWORD}"}, to)
+
+	// single quoted values are written as is, only yaml can escape single quotes
+	hostAndPassword := WithLookup(func(key string) (string, bool) {
+		value, exists := map[string]string{"DB_HOST": "db host", "DB_PASSWORD": "it's a #secret"}[key]
+		return value, exists
+	})
+
+	to = database{}
+	require.NoError(t, newFile(t, "single.yaml", WithFs(fs), hostAndPassword).Unmarshal(&to))
+	assert.Equal(t, database{Host: "db host", Password: "it's a #secret"}, to)
+
+	require.Error(t, newFile(t, "single.toml", WithFs(fs), hostAndPassword).Unmarshal(&database{}))
+
+	// undefined variables are kept as is, unless interpolation is strict
+	to = database{}
+	require.NoError(t, newFile(t, "strict.yaml", WithFs(fs), noVars).Unmarshal(&to))
+	assert.Equal(t, database{Host: "${DB_HOST}"}, to)
+
+	err := newFile(t, "strict.yaml", WithFs(fs), noVars, StrictInterpolation()).Unmarshal(&to)
+	require.EqualError(t, err, `unable to interpolate file "strict.yaml": line 1: variable DB_HOST is not defined`)
+}
@@ -0,0 +1,120 @@
+package sourcefile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Decoder decodes the content of a file.
+type Decoder interface {
+	Decode(to interface{}) error
+}
+
+// DecoderFactory creates a decoder that reads from r. If strict is true, the
+// decoder must fail when r contains a key that does not exist in the destination.
+type DecoderFactory func(r io.Reader, strict bool) Decoder
+
+// formats contains all registered formats. Names are kept in the order
+// formats are tried in when sniffing content: last registered first.
+var formats = struct {
+	sync.RWMutex
+	names       []string
+	byName      map[string]DecoderFactory
+	byExtension map[string]string
+}{
+	byName:      make(map[string]DecoderFactory),
+	byExtension: make(map[string]string),
+}
+
+// RegisterFormat registers a format, which will then be used to decode files with
+// one of the provided extensions (without the leading dot), or files configured
+// to use the format (see WithFormat). Formats are also tried when the format of a
+// file can't be known any other way, last registered first. Registering an already
+// registered format replaces it. The json, toml and yaml (yml) formats are built-in.
+func RegisterFormat(name string, extensions []string, factory DecoderFactory) {
+	name = strings.ToLower(name)
+
+	formats.Lock()
+	defer formats.Unlock()
+
+	if _, exists := formats.byName[name]; exists {
+		for i, n := range formats.names {
+			if n == name {
+				formats.names = append(formats.names[:i], formats.names[i+1:]...)
+				break
+			}
+		}
+		for ext, n := range formats.byExtension {
+			if n == name {
+				delete(formats.byExtension, ext)
+			}
+		}
+	}
+
+	formats.names = append([]string{name}, formats.names...)
+	formats.byName[name] = factory
+	for _, ext := range extensions {
+		formats.byExtension[strings.ToLower(strings.TrimPrefix(ext, "."))] = name
+	}
+}
+
+func lookupFormat(name string) (DecoderFactory, bool) {
+	formats.RLock()
+	defer formats.RUnlock()
+	factory, exists := formats.byName[name]
+	return factory, exists
+}
+
+func lookupFormatByExtension(ext string) (string, bool) {
+	formats.RLock()
+	defer formats.RUnlock()
+	name, exists := formats.byExtension[ext]
+	return name, exists
+}
+
+func registeredFormats() []string {
+	formats.RLock()
+	defer formats.RUnlock()
+	return append([]string(nil), formats.names...)
+}
+
+func init() { // nolint: gochecknoinits
+	// yaml is the most permissive format, it is registered first to be tried last
+	RegisterFormat("yaml", []string{"yaml", "yml"}, func(r io.Reader, strict bool) Decoder {
+		decoder := yaml.NewDecoder(r)
+		decoder.KnownFields(strict)
+		return decoder
+	})
+	RegisterFormat("toml", []string{"toml"}, func(r io.Reader, strict bool) Decoder {
+		return &tomlDecoder{r: r, strict: strict}
+	})
+	RegisterFormat("json", []string{"json"}, func(r io.Reader, strict bool) Decoder {
+		decoder := json.NewDecoder(r)
+		if strict {
+			decoder.DisallowUnknownFields()
+		}
+		return decoder
+	})
+}
+
+type tomlDecoder struct {
+	r      io.Reader
+	strict bool
+}
+
+func (d *tomlDecoder) Decode(to interface{}) error {
+	meta, err := toml.DecodeReader(d.r, to)
+	if err != nil {
+		return err
+	}
+	if undecoded := meta.Undecoded(); d.strict && len(undecoded) > 0 {
+		return fmt.Errorf("unknown fields %v", undecoded)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package sourcefile
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keyValueDecoder decodes lines of key:value into a map[string]string.
+type keyValueDecoder struct{ r io.Reader }
+
+func (d keyValueDecoder) Decode(to interface{}) error {
+	values, ok := to.(*map[string]string)
+	if !ok {
+		return io.ErrUnexpectedEOF
+	}
+	if *values == nil {
+		*values = make(map[string]string)
+	}
+
+	scanner := bufio.NewScanner(d.r)
+	for scanner.Scan() {
+		kv := strings.SplitN(scanner.Text(), ":", 2)
+		if len(kv) != 2 {
+			return io.ErrUnexpectedEOF
+		}
+		(*values)[kv[0]] = strings.TrimSpace(kv[1])
+	}
+	return scanner.Err()
+}
+
+func Test_RegisterFormat(t *testing.T) {
+	RegisterFormat("KV", []string{".kv"}, func(r io.Reader, strict bool) Decoder { return keyValueDecoder{r: r} })
+	defer func() {
+		formats.Lock()
+		defer formats.Unlock()
+		formats.names = formats.names[1:]
+		delete(formats.byName, "kv")
+		delete(formats.byExtension, "kv")
+	}()
+
+	assert.Equal(t, []string{"kv", "json", "toml", "yaml"}, registeredFormats())
+	name, exists := lookupFormatByExtension("kv")
+	assert.True(t, exists)
+	assert.Equal(t, "kv", name)
+
+	var fs = afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "file.kv", []byte("hello:world"), 0600))
+	require.NoError(t, afero.WriteFile(fs, "file", []byte("hello:world"), 0600))
+
+	for _, path := range []string{"file.kv", "file"} {
+		var to map[string]string
+		require.NoError(t, newFile(t, path, WithFs(fs)).Unmarshal(&to))
+		assert.Equal(t, map[string]string{"hello": "world"}, to)
+	}
+
+	// registering again replaces the format
+	RegisterFormat("kv", []string{"keyvalue"}, func(r io.Reader, strict bool) Decoder { return keyValueDecoder{r: r} })
+	assert.Equal(t, []string{"kv", "json", "toml", "yaml"}, registeredFormats())
+	_, exists = lookupFormatByExtension("kv")
+	assert.False(t, exists)
+	_, exists = lookupFormatByExtension("keyvalue")
+	assert.True(t, exists)
+}
+
+func Test_builtinFormats(t *testing.T) {
+	type helloWorld struct {
+		Hello string `json:"hello" yaml:"hello" toml:"hello"`
+	}
+
+	for format, content := range map[string]string{
+		"json": `{"hello": "world", "world": "hello"}`,
+		"yaml": "hello: world\nworld: hello",
+		"toml": "hello = \"world\"\nworld = \"hello\"",
+	} {
+		factory, exists := lookupFormat(format)
+		require.True(t, exists, format)
+
+		var to helloWorld
+		require.NoError(t, factory(strings.NewReader(content), false).Decode(&to), format)
+		assert.Equal(t, helloWorld{Hello: "world"}, to, format)
+		require.Error(t, factory(strings.NewReader(content), true).Decode(&to), format)
+	}
+
+	factory, _ := lookupFormat("toml")
+	require.Error(t, factory(strings.NewReader("hello = "), false).Decode(reflect.New(reflect.TypeOf("")).Interface()))
+}
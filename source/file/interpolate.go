@@ -0,0 +1,322 @@
+package sourcefile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// interpolate replaces, in content, variables with their value returned by lookup:
+// ${VAR} is replaced by the value of VAR, ${VAR:-default} by default if VAR is
+// undefined or empty, and ${VAR-default} by default if VAR is undefined. $${ is
+// replaced by ${ without interpolation, and comments are kept as is.
+//
+// Values can't change the structure of the file. They are escaped inside double
+// quoted strings (and toml """ strings), written with single quotes doubled inside
+// yaml single quoted strings, and written as is inside toml literal strings if they
+// don't contain single quotes. In yaml block scalars (| and >), their lines are
+// indented like the line of the variable. Elsewhere, they are written as is only if
+// they are plain values, like hostnames, numbers or durations (see isPlainValue), as
+// double quoted strings if the variable is the whole value, like in
+// "password: ${DB_PASSWORD}", or an error is returned. Format is the name of the
+// format of the content, if known.
+//
+// Undefined variables without defaults, and unterminated variables, are kept
+// as is, unless strict is true, in which case an error is returned.
+func interpolate(content, format string, lookup func(key string) (string, bool), strict bool) (string, error) {
+	var (
+		b            strings.Builder
+		line         = 1
+		lineStart    = 0
+		commentStart = -1
+		// quote is the delimiter of the string being read, if any
+		quote string
+		// blockIndent is the indentation of the header of the
+		// yaml block scalar being read, if any, -1 otherwise
+		blockIndent = -1
+	)
+
+	for i := 0; i < len(content); i++ {
+		switch c := content[i]; {
+		case c == '\n':
+			valueEnd := i
+			if commentStart >= 0 {
+				valueEnd = commentStart
+			}
+			if quote == "" && blockIndent < 0 && isBlockScalarHeader(content[lineStart:valueEnd]) {
+				blockIndent = indentation(content[lineStart:])
+			}
+
+			line++
+			lineStart = i + 1
+			commentStart = -1
+
+			// single line quotes are not tracked across lines,
+			// to not be misled by unbalanced quotes in plain values
+			if quote == `"` || quote == "'" {
+				quote = ""
+			}
+
+			// block scalars end with the first line that is not blank nor more indented
+			if next := content[lineStart : lineStart+lineLength(content[lineStart:])]; blockIndent >= 0 &&
+				strings.TrimSpace(next) != "" && indentation(next) <= blockIndent {
+				blockIndent = -1
+			}
+		case blockIndent >= 0 && c != '$':
+			// block scalars are only made of text
+		case c == '#' && quote == "" && (i == lineStart || content[i-1] == ' ' || content[i-1] == '\t'):
+			end := i + lineLength(content[i:])
+			b.WriteString(content[i:end])
+			commentStart = i
+			i = end - 1
+			continue
+		case c == '\\' && (quote == `"` || quote == `"""`) && i+1 < len(content) && content[i+1] != '\n':
+			// escaped characters, like quotes, are kept as is
+			b.WriteByte(c)
+			i++
+		case quote == "'" && strings.HasPrefix(content[i:], "''"):
+			// escaped single quote of yaml
+			b.WriteString("''")
+			i++
+			continue
+		case quote != "" && strings.HasPrefix(content[i:], quote):
+			b.WriteString(quote)
+			i += len(quote) - 1
+			quote = ""
+			continue
+		case quote == "" && (c == '"' || (c == '\'' && isValueStart(content[lineStart:i]))):
+			// apostrophes in plain values do not start strings
+			quote = string(c)
+			if strings.HasPrefix(content[i:], strings.Repeat(quote, 3)) {
+				quote = strings.Repeat(quote, 3)
+			}
+			b.WriteString(quote)
+			i += len(quote) - 1
+			continue
+		case c == '$' && strings.HasPrefix(content[i:], "$${"):
+			b.WriteString("${")
+			i += 2
+			continue
+		case c == '$' && strings.HasPrefix(content[i:], "${"):
+			end := strings.IndexByte(content[i:lineStart+lineLength(content[lineStart:])], '}')
+			if end < 0 {
+				if strict {
+					return "", fmt.Errorf("line %d: unterminated variable %s", line, content[i:i+lineLength(content[i:])])
+				}
+				break
+			}
+
+			value, defined, err := expandVariable(content[i+2:i+end], lookup)
+			switch {
+			case err != nil:
+				return "", fmt.Errorf("line %d: %w", line, err)
+			case !defined && strict:
+				return "", fmt.Errorf("line %d: variable %s is not defined", line, content[i+2:i+end])
+			case !defined:
+				b.WriteString(content[i : i+end+1])
+				i += end
+				continue
+			}
+
+			if value, err = formatValue(value, format, variablePosition{
+				quote:  quote,
+				block:  blockIndent >= 0,
+				indent: content[lineStart : lineStart+indentation(content[lineStart:])],
+				before: content[lineStart:i],
+				after:  content[i+end+1:],
+			}); err != nil {
+				// the value may be a secret, it is not part of the error
+				return "", fmt.Errorf("line %d: value of %s: %w", line, content[i:i+end+1], err)
+			}
+
+			b.WriteString(value)
+			i += end
+			continue
+		}
+
+		b.WriteByte(content[i])
+	}
+
+	return b.String(), nil
+}
+
+// isBlockScalarHeader returns true if the line, without its comment,
+// starts a yaml block scalar, like "key: |" or "- >-".
+func isBlockScalarHeader(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+
+	last := fields[len(fields)-1]
+	if (last[0] != '|' && last[0] != '>') || strings.Trim(last[1:], "+-0123456789") != "" {
+		return false
+	}
+
+	if len(fields) == 1 {
+		return true
+	}
+
+	previous := fields[len(fields)-2]
+	return strings.HasSuffix(previous, ":") || previous == "-"
+}
+
+// isValueStart returns true if a value can start after before, which is the
+// start of its line: it follows a key, a sequence entry indicator, the start
+// of a list, an object or an element, or nothing.
+func isValueStart(before string) bool {
+	trimmed := strings.TrimRight(before, " \t")
+	return trimmed == "" || strings.ContainsRune(":=-[{,", rune(trimmed[len(trimmed)-1]))
+}
+
+// indentation returns the number of spaces content starts with.
+func indentation(content string) int {
+	return len(content) - len(strings.TrimLeft(content, " "))
+}
+
+// lineLength returns the length of the first line of content, without its newline.
+func lineLength(content string) int {
+	if end := strings.IndexByte(content, '\n'); end >= 0 {
+		return end
+	}
+	return len(content)
+}
+
+// expandVariable returns the value of the expression between ${ and },
+// and whether the variable, or its default, is defined.
+func expandVariable(expr string, lookup func(key string) (string, bool)) (string, bool, error) {
+	var (
+		name, def  = expr, ""
+		hasDefault bool
+		ifEmpty    bool
+	)
+
+	if i := strings.IndexByte(expr, '-'); i >= 0 {
+		name, def, hasDefault = expr[:i], expr[i+1:], true
+		if strings.HasSuffix(name, ":") {
+			name, ifEmpty = name[:len(name)-1], true
+		}
+	}
+
+	if name == "" {
+		return "", false, fmt.Errorf("empty variable name in ${%s}", expr)
+	}
+
+	value, exists := lookup(name)
+	if hasDefault && (!exists || (ifEmpty && value == "")) {
+		return def, true, nil
+	}
+
+	return value, exists, nil
+}
+
+// variablePosition describes where a variable is found.
+type variablePosition struct {
+	// quote is the delimiter of the string the variable is in, if any.
+	quote string
+	// block is true if the variable is in a yaml block scalar.
+	block bool
+	// indent is the indentation of the line of the variable.
+	indent string
+	// before and after are the rest of the line of the variable.
+	before, after string
+}
+
+// formatValue formats the value of a variable found at pos in content
+// of the provided format, so the value can't change the structure of the file.
+func formatValue(value, format string, pos variablePosition) (string, error) {
+	switch {
+	case pos.block:
+		return strings.ReplaceAll(value, "\n", "\n"+pos.indent), nil
+	case pos.quote == `"` || pos.quote == `"""`:
+		return escapeQuoted(value), nil
+	case pos.quote == "'" && format == "yaml":
+		if strings.ContainsAny(value, "\r\n") {
+			return "", errors.New("it contains a newline, which can't be inside single quotes")
+		}
+		return strings.ReplaceAll(value, "'", "''"), nil
+	case pos.quote == "'" || pos.quote == "'''":
+		// single quotes can't be escaped in toml literal strings
+		if strings.ContainsRune(value, '\'') {
+			return "", errors.New("it contains single quotes, which can't be inside literal strings")
+		}
+		if pos.quote == "'" && strings.ContainsAny(value, "\r\n") {
+			return "", errors.New("it contains a newline, which can't be inside single quotes")
+		}
+		return value, nil
+	case isPlainValue(value):
+		return value, nil
+	case isWholeValue(pos.before, pos.after):
+		return `"` + escapeQuoted(value) + `"`, nil
+	default:
+		return "", errors.New("it contains special characters, the variable must be the whole value or be inside double quotes")
+	}
+}
+
+// isPlainValue returns true if the value can be written as is outside of quotes in
+// json, yaml or toml: it is empty, or only contains letters, digits, '_', '.', '/',
+// '+' and '-', and does not start with '-' unless it is a negative number.
+func isPlainValue(value string) bool {
+	if strings.HasPrefix(value, "-") && (len(value) == 1 || !strings.ContainsAny(value[1:2], "0123456789.")) {
+		return false
+	}
+
+	for _, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("_./+-", r):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// isWholeValue returns true if a variable found between before and after, which are
+// the rest of its line, is a whole value: it follows a key ("key: " in yaml and json,
+// "key = " in toml), a sequence entry indicator ("- " in yaml) or the start of a line
+// or of a list element, and is followed by nothing, a comment, or the end of an element.
+func isWholeValue(before, after string) bool {
+	var (
+		trimmed = strings.TrimRight(before, " \t")
+		spaced  = len(trimmed) < len(before)
+	)
+
+	switch {
+	case strings.TrimSpace(trimmed) == "":
+	case strings.HasSuffix(trimmed, "[") || strings.HasSuffix(trimmed, ","):
+	case spaced && (strings.HasSuffix(trimmed, ":") || strings.HasSuffix(trimmed, "=")):
+	case spaced && strings.TrimSpace(trimmed) == "-":
+	default:
+		return false
+	}
+
+	after = after[:lineLength(after)]
+	rest := strings.TrimLeft(after, " \t\r")
+
+	switch {
+	case rest == "":
+		return true
+	case rest[0] == '#':
+		return len(rest) < len(after)
+	default:
+		return strings.ContainsRune(",]}", rune(rest[0]))
+	}
+}
+
+// escapeQuoted escapes the value to be part of a double quoted
+// string of json, yaml or toml, which all handle json escapes.
+func escapeQuoted(value string) string {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value) // encoding a string never fails
+
+	// remove the surrounding quotes and the trailing newline
+	escaped := strings.TrimSuffix(buf.String(), "\n")
+	return escaped[1 : len(escaped)-1]
+}
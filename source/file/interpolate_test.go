@@ -0,0 +1,130 @@
+package sourcefile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_interpolate(t *testing.T) {
+	var tests = map[string]struct {
+		content         string
+		format          string
+		strict          bool
+		expected        string
+		expectedFailure string
+	}{
+		"no variables": {
+			content:  "host: localhost\nprice: $5",
+			expected: "host: localhost\nprice: $5",
+		}, "variables": {
+			content:  "host: ${HOST}\nurl: http://${HOST}:${PORT}/\noffset: ${NEGATIVE}",
+			expected: "host: db\nurl: http://db:5432/\noffset: -5",
+		}, "undefined variables": {
+			content:  "host: ${UNDEFINED}",
+			expected: "host: ${UNDEFINED}",
+		}, "strict undefined variables": {
+			content:         "host: db\npassword: ${UNDEFINED}",
+			strict:          true,
+			expectedFailure: "line 2: variable UNDEFINED is not defined",
+		}, "defaults": {
+			content:  "${UNDEFINED:-localhost} ${EMPTY:-localhost} ${UNDEFINED-localhost} ${EMPTY-localhost} ${HOST:-localhost}",
+			strict:   true,
+			expected: "localhost localhost localhost  db",
+		}, "defaults with special characters": {
+			content:  `host: "${UNDEFINED:-a:b-c}"`,
+			expected: `host: "a:b-c"`,
+		}, "escaped": {
+			content:  "literal: $${HOST} ${HOST}",
+			expected: "literal: ${HOST} db",
+		}, "special characters in double quoted strings": {
+			content:  `{"password": "${PASSWORD}", "escaped": "\"${HOST}\"", "other": "${HOST}"}`,
+			expected: `{"password": "p\"a\\s:s\nw<o>rd", "escaped": "\"db\"", "other": "db"}`,
+		}, "special characters in whole values": {
+			content: "comment: ${COMMENT}\nanchor: ${ANCHOR} # comment\ninjection: ${INJECTION}\n" +
+				"toml = ${COMMENT}\n- ${ANCHOR}\nflow: [${HOST}, ${ANCHOR}]\njson: {\"a\": ${ANCHOR}}",
+			expected: "comment: \"ab #cd\"\nanchor: \"*anchor\" # comment\ninjection: \"x\\ndebug: true\"\n" +
+				"toml = \"ab #cd\"\n- \"*anchor\"\nflow: [db, \"*anchor\"]\njson: {\"a\": \"*anchor\"}",
+		}, "special characters in the middle of a value": {
+			content:         "host: db\nurl: http://${ANCHOR}/",
+			expectedFailure: "line 2: value of ${ANCHOR}: it contains special characters, the variable must be the whole value or be inside double quotes",
+		}, "yaml single quoted strings": {
+			content:  "host: '${HOST}'\nmessage: '${QUOTE}'\nescaped: 'it''s ${CMD}'\nplain: it's ${HOST}",
+			format:   "yaml",
+			expected: "host: 'db'\nmessage: 'it''s a test'\nescaped: 'it''s echo hi there'\nplain: it's db",
+		}, "newlines in yaml single quoted strings": {
+			content:         "host: db\npassword: '${PASSWORD}'",
+			format:          "yaml",
+			expectedFailure: "line 2: value of ${PASSWORD}: it contains a newline, which can't be inside single quotes",
+		}, "toml literal strings": {
+			content:  "command = '${CMD}'\nscript = '''\n${INJECTION}\n'''",
+			format:   "toml",
+			expected: "command = 'echo hi there'\nscript = '''\nx\ndebug: true\n'''",
+		}, "single quotes in toml literal strings": {
+			content:         "command = '${CMD}'\nmessage = '''${QUOTE}'''",
+			format:          "toml",
+			expectedFailure: "line 2: value of ${QUOTE}: it contains single quotes, which can't be inside literal strings",
+		}, "single quotes of unknown formats": {
+			content:         "message: '${QUOTE}'",
+			expectedFailure: "line 1: value of ${QUOTE}: it contains single quotes, which can't be inside literal strings",
+		}, "toml multi-line basic strings": {
+			content:  "password = \"\"\"\n${PASSWORD} # ${CMD}\n\"\"\"\nhost = ${HOST}",
+			format:   "toml",
+			expected: "password = \"\"\"\np\\\"a\\\\s:s\\nw<o>rd # echo hi there\n\"\"\"\nhost = db",
+		}, "yaml block scalars": {
+			content: "script: |\n  ${CMD}\n\n  # ${QUOTE}\n    ${INJECTION}\nlist:\n  - >-\n    ${CMD} # ${HOST}\nhost: ${CMD}",
+			format:  "yaml",
+			expected: "script: |\n  echo hi there\n\n  # it's a test\n    x\n    debug: true\nlist:\n  - >-\n    echo hi there # db\n" +
+				"host: \"echo hi there\"",
+		}, "comments are kept as is": {
+			content:  "# use ${PASSWORD} or ${UNDEFINED\nhost: ${HOST} # ${ANCHOR}",
+			strict:   true,
+			expected: "# use ${PASSWORD} or ${UNDEFINED\nhost: db # ${ANCHOR}",
+		}, "quotes are not tracked across lines": {
+			content:  "description: it\"s\npassword: \"${PASSWORD}\"",
+			expected: "description: it\"s\npassword: \"p\\\"a\\\\s:s\\nw<o>rd\"",
+		}, "unterminated variable": {
+			content:  "host: db\nport: ${PORT\nother: }",
+			expected: "host: db\nport: ${PORT\nother: }",
+		}, "strict unterminated variable": {
+			content:         "host: db\nport: ${PORT\nother: }",
+			strict:          true,
+			expectedFailure: "line 2: unterminated variable ${PORT",
+		}, "empty variable name": {
+			content:         "host: ${:-db}",
+			expectedFailure: "line 1: empty variable name in ${:-db}",
+		},
+	}
+
+	var lookup = func(key string) (string, bool) {
+		value, exists := map[string]string{
+			"HOST":      "db",
+			"PORT":      "5432",
+			"EMPTY":     "",
+			"PASSWORD":  "p\"a\\s:s\nw<o>rd",
+			"NEGATIVE":  "-5",
+			"COMMENT":   "ab #cd",
+			"ANCHOR":    "*anchor",
+			"INJECTION": "x\ndebug: true",
+			"CMD":       "echo hi there",
+			"QUOTE":     "it's a test",
+		}[key]
+		return value, exists
+	}
+
+	for name, test := range tests {
+		var test = test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			content, err := interpolate(test.content, test.format, lookup, test.strict)
+			if test.expectedFailure != "" {
+				require.EqualError(t, err, test.expectedFailure)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, content)
+		})
+	}
+}
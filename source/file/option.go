@@ -1,5 +1,12 @@
 package sourcefile
 
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
 // Option defines the function signature to apply options.
 type Option func(f *File)
 
@@ -14,3 +21,49 @@ func MayNotExist() Option {
 func FailOnUnknownFields() Option {
 	return func(f *File) { f.strictUnmarshal = true }
 }
+
+// WatchInterval sets the interval at which the file is
+// polled to detect changes when watched (see File.Watch).
+// Non-positive intervals are ignored, and the default one is kept.
+func WatchInterval(interval time.Duration) Option {
+	return func(f *File) {
+		if interval > 0 {
+			f.watchInterval = interval
+		}
+	}
+}
+
+// WithFs sets the filesystem the file is read from,
+// which is the read-only os filesystem by default.
+func WithFs(fs afero.Fs) Option {
+	return func(f *File) { f.fs = fs }
+}
+
+// WithFormat sets the format of the file (see RegisterFormat), instead of
+// relying on its extension, for files with no or misleading extensions.
+func WithFormat(format string) Option {
+	return func(f *File) { f.format = strings.ToLower(format) }
+}
+
+// WithoutInterpolation disables the replacement of variables, like
+// ${DB_PASSWORD} or ${DB_HOST:-localhost}, by their value before decoding
+// the file, which is enabled by default; files are then read exactly as they
+// are. Interpolated values are escaped or quoted so they can't change the
+// structure of the file, which is why values with special characters, like
+// spaces, quotes or '#', can't be used in the middle of unquoted text.
+func WithoutInterpolation() Option {
+	return func(f *File) { f.interpolate = false }
+}
+
+// StrictInterpolation tells the file decoder to fail if a variable
+// without default value is not defined, or if a variable is not
+// terminated, instead of keeping them as is.
+func StrictInterpolation() Option {
+	return func(f *File) { f.strictInterpolation = true }
+}
+
+// WithLookup sets the function used to get the value of variables
+// during interpolation, which is os.LookupEnv by default.
+func WithLookup(lookup func(key string) (string, bool)) Option {
+	return func(f *File) { f.lookupEnv = lookup }
+}
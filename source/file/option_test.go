@@ -2,6 +2,9 @@ package sourcefile
 
 import (
 	"testing"
+	"time"
+
+	"github.com/spf13/afero"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -21,3 +24,51 @@ func Test_FailOnUnknownFields(t *testing.T) {
 	FailOnUnknownFields()(f)
 	assert.True(t, f.strictUnmarshal)
 }
+
+func Test_WatchInterval(t *testing.T) {
+	f := newFile(t, "")
+	assert.Equal(t, 5*time.Second, f.watchInterval)
+	WatchInterval(time.Minute)(f)
+	assert.Equal(t, time.Minute, f.watchInterval)
+	WatchInterval(0)(f)
+	assert.Equal(t, time.Minute, f.watchInterval)
+	WatchInterval(-time.Second)(f)
+	assert.Equal(t, time.Minute, f.watchInterval)
+}
+
+func Test_WithFs(t *testing.T) {
+	var fs = afero.NewMemMapFs()
+
+	f := newFile(t, "")
+	WithFs(fs)(f)
+	assert.Equal(t, fs, f.fs)
+}
+
+func Test_WithFormat(t *testing.T) {
+	f := newFile(t, "")
+	assert.Equal(t, "", f.format)
+	WithFormat("YAML")(f)
+	assert.Equal(t, "yaml", f.format)
+}
+
+func Test_WithoutInterpolation(t *testing.T) {
+	f := newFile(t, "")
+	assert.True(t, f.interpolate)
+	WithoutInterpolation()(f)
+	assert.False(t, f.interpolate)
+}
+
+func Test_StrictInterpolation(t *testing.T) {
+	f := newFile(t, "")
+	assert.False(t, f.strictInterpolation)
+	StrictInterpolation()(f)
+	assert.True(t, f.strictInterpolation)
+}
+
+func Test_WithLookup(t *testing.T) {
+	f := newFile(t, "")
+	WithLookup(func(string) (string, bool) { return "value", true })(f)
+	value, exists := f.lookupEnv("KEY")
+	assert.True(t, exists)
+	assert.Equal(t, "value", value)
+}
@@ -0,0 +1,195 @@
+// Package sourceflag sources configuration from command-line flags.
+package sourceflag
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/krostar/config"
+	"github.com/krostar/config/trivialerr"
+)
+
+// Flag implements config.Source to fetch values from command-line
+// flags, generated from the configuration structure.
+type Flag struct {
+	flagSet *flag.FlagSet
+
+	// flag names indexed by config tree path
+	names map[string]string
+	// raw values of explicitly passed flags indexed by config tree path
+	values map[string]string
+}
+
+// New returns a new flag source. One flag is registered per leaf of the
+// config tree of cfg (see config.WalkTreeLeaves), named after the
+// kebab-cased fields names, like --http.listen-address, or after the `cfg`
+// tag name if any. The `desc` tag is used as the flag usage. Slices, arrays
+// and maps are read from separator-delimited values like --hosts=a,b, or
+// json; see config.InitializeNewValueOfTypeWithDelimitedString. Args, usually
+// os.Args[1:], are then parsed, and only flags explicitly passed are used to
+// set values, to not override values set by defaults or other sources.
+func New(cfg interface{}, args []string, opts ...Option) config.SourceCreationFunc {
+	return func() (config.Source, error) {
+		f := Flag{
+			flagSet: flag.NewFlagSet(os.Args[0], flag.ContinueOnError),
+			names:   make(map[string]string),
+			values:  make(map[string]string),
+		}
+
+		for _, opt := range opts {
+			opt(&f)
+		}
+
+		var err error
+		config.WalkTreeLeaves(reflect.TypeOf(cfg), func(leaf config.TreeLeaf) {
+			if err == nil && leaf.TreePath != "" {
+				err = f.register(leaf)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := f.flagSet.Parse(args); err != nil {
+			return nil, fmt.Errorf("unable to parse flags: %w", err)
+		}
+
+		f.flagSet.Visit(func(fl *flag.Flag) {
+			if v, ok := fl.Value.(*flagValue); ok && v.source == &f {
+				f.values[v.treePath] = v.raw
+			}
+		})
+
+		return &f, nil
+	}
+}
+
+func (f *Flag) register(leaf config.TreeLeaf) error {
+	var names = make([]string, len(leaf.Fields))
+	for i, field := range leaf.Fields {
+		names[i] = flagName(field)
+	}
+	name := strings.Join(names, ".")
+
+	if f.flagSet.Lookup(name) != nil {
+		return fmt.Errorf("flag %s of config tree path %s is already defined", name, leaf.TreePath)
+	}
+
+	var field reflect.StructField
+	if len(leaf.Fields) > 0 {
+		field = leaf.Fields[len(leaf.Fields)-1]
+	}
+
+	value := flagValue{source: f, treePath: leaf.TreePath, typ: leaf.Type}
+	value.sep, value.kvsep = config.DelimitersOfStructField(field)
+
+	f.names[leaf.TreePath] = name
+	f.flagSet.Var(&value, name, field.Tag.Get("desc"))
+
+	return nil
+}
+
+// Name implements config.Source interface.
+func (f *Flag) Name() string { return "flag" }
+
+// DescribeKey implements config.SourceKeyDescriber interface.
+func (f *Flag) DescribeKey(treePath string) string {
+	if name, exists := f.names[treePath]; exists {
+		return "--" + name
+	}
+	return ""
+}
+
+// Args returns the non-flag arguments remaining after parsing.
+func (f *Flag) Args() []string { return f.flagSet.Args() }
+
+// SetValueFromConfigTreePath implements config.SourceSetValueFromConfigTreePath.
+func (f *Flag) SetValueFromConfigTreePath(v *reflect.Value, treePath string) (bool, error) {
+	raw, exists := f.values[treePath]
+	if !exists {
+		return false, fmt.Errorf("flag of key %s is not set: %w", treePath, trivialerr.ErrNotFound)
+	}
+
+	value := f.flagSet.Lookup(f.names[treePath]).Value.(*flagValue)
+
+	newV, err := value.decode(v.Type(), raw)
+	if err != nil {
+		return false, fmt.Errorf("unable to initialize new value from %q: %w", raw, err)
+	}
+
+	return config.SetNewValue(v, newV)
+}
+
+// flagName returns the name of the field in the `cfg` tag, or its kebab-cased name.
+func flagName(field reflect.StructField) string {
+	if tag := strings.Split(field.Tag.Get("cfg"), ",")[0]; tag != "" {
+		return strings.ToLower(tag)
+	}
+	return kebabCase(field.Name)
+}
+
+// kebabCase converts a go identifier to kebab case, keeping
+// acronyms together: HTTPServer becomes http-server.
+func kebabCase(name string) string {
+	var (
+		runes = []rune(name)
+		b     strings.Builder
+	)
+
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			var (
+				afterLower  = !unicode.IsUpper(runes[i-1])
+				beforeLower = i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			)
+			if afterLower || (unicode.IsUpper(runes[i-1]) && beforeLower) {
+				b.WriteRune('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
+
+// flagValue implements flag.Value to check and keep raw values of flags.
+type flagValue struct {
+	source   *Flag
+	treePath string
+	typ      reflect.Type
+	raw      string
+
+	// separators of delimited values
+	sep, kvsep string
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.raw
+}
+
+// Set checks the value can be decoded, which allows to fail when parsing flags.
+func (v *flagValue) Set(raw string) error {
+	if _, err := v.decode(v.typ, raw); err != nil {
+		return err
+	}
+	v.raw = raw
+	return nil
+}
+
+// decode creates a new value of the provided type from a raw value of the flag.
+func (v *flagValue) decode(typ reflect.Type, raw string) (*reflect.Value, error) {
+	if config.IsDelimitedType(typ) {
+		return config.InitializeNewValueOfTypeWithDelimitedString(typ, raw, v.sep, v.kvsep)
+	}
+	return config.InitializeNewValueOfTypeWithString(typ, raw)
+}
+
+// IsBoolFlag allows boolean flags to be set without value, like --debug.
+func (v *flagValue) IsBoolFlag() bool { return v.typ.Kind() == reflect.Bool }
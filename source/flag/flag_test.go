@@ -0,0 +1,159 @@
+package sourceflag
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/krostar/config"
+	"github.com/krostar/config/trivialerr"
+)
+
+type flagCfg struct {
+	HTTP struct {
+		ListenAddress string        `desc:"address to listen on"`
+		Timeout       time.Duration `cfg:"Delay"`
+	}
+	Debug    bool
+	Hosts    []string
+	Database *struct {
+		Host string
+		Port int
+	}
+	Ignored string `cfg:"-"`
+}
+
+func newFlag(t *testing.T, args []string, opts ...Option) *Flag {
+	f, err := New(&flagCfg{}, args, opts...)()
+	require.NoError(t, err)
+	return f.(*Flag)
+}
+
+func Test_New(t *testing.T) {
+	f := newFlag(t, []string{"--http.listen-address=:9000", "--debug", "--database.port", "5432", "remaining"})
+
+	assert.Equal(t, map[string]string{
+		"http.listenaddress": "http.listen-address",
+		"http.delay":         "http.delay",
+		"debug":              "debug",
+		"hosts":              "hosts",
+		"database.host":      "database.host",
+		"database.port":      "database.port",
+	}, f.names)
+	assert.Equal(t, map[string]string{
+		"http.listenaddress": ":9000",
+		"debug":              "true",
+		"database.port":      "5432",
+	}, f.values)
+	assert.Equal(t, []string{"remaining"}, f.Args())
+	assert.Equal(t, "address to listen on", f.flagSet.Lookup("http.listen-address").Usage)
+}
+
+func Test_New_failures(t *testing.T) {
+	var output bytes.Buffer
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.SetOutput(&output)
+
+	_, err := New(&flagCfg{}, []string{"--database.port=http"}, WithFlagSet(flagSet))()
+	require.Error(t, err)
+	assert.Contains(t, output.String(), "database.port")
+
+	flagSet = flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.SetOutput(&output)
+	_, err = New(&flagCfg{}, []string{"--unknown"}, WithFlagSet(flagSet))()
+	require.Error(t, err)
+
+	flagSet = flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.SetOutput(&output)
+	_, err = New(&flagCfg{}, []string{"-h"}, WithFlagSet(flagSet))()
+	require.True(t, errors.Is(err, flag.ErrHelp))
+
+	var duplicated struct {
+		Name  string
+		Other string `cfg:"name"`
+	}
+	_, err = New(&duplicated, nil)()
+	require.Error(t, err)
+}
+
+func TestFlag_DescribeKey(t *testing.T) {
+	f := newFlag(t, nil)
+	assert.Equal(t, "--http.listen-address", f.DescribeKey("http.listenaddress"))
+	assert.Equal(t, "", f.DescribeKey("unknown"))
+}
+
+func TestFlag_SetValueFromConfigTreePath(t *testing.T) {
+	f := newFlag(t, []string{"--http.delay=3s"})
+
+	var v = reflect.New(reflect.TypeOf(time.Duration(0))).Elem()
+	isSet, err := f.SetValueFromConfigTreePath(&v, "http.delay")
+	require.NoError(t, err)
+	assert.True(t, isSet)
+	assert.Equal(t, 3*time.Second, v.Interface())
+
+	_, err = f.SetValueFromConfigTreePath(&v, "http.listenaddress")
+	require.Error(t, err)
+	assert.True(t, trivialerr.IsTrivial(err))
+
+	// values are checked against the type of the flag, not the one asked
+	v = reflect.New(reflect.TypeOf(0)).Elem()
+	_, err = f.SetValueFromConfigTreePath(&v, "http.delay")
+	require.Error(t, err)
+}
+
+func Test_Load(t *testing.T) {
+	var cfg = flagCfg{Debug: true, Hosts: []string{"a"}}
+	cfg.HTTP.ListenAddress = ":8080"
+
+	require.NoError(t, config.Load(&cfg, config.WithSources(
+		New(&cfg, []string{"--http.delay=3s", `--hosts=["b","c"]`, "--debug=false"}),
+	)))
+	assert.Equal(t, ":8080", cfg.HTTP.ListenAddress)
+	assert.Equal(t, 3*time.Second, cfg.HTTP.Timeout)
+	assert.False(t, cfg.Debug)
+	assert.Equal(t, []string{"b", "c"}, cfg.Hosts)
+	assert.Nil(t, cfg.Database)
+}
+
+func Test_Load_delimited(t *testing.T) {
+	var cfg struct {
+		Hosts  []string
+		Ports  []int
+		Labels map[string]string `sep:";" kvsep:":"`
+	}
+
+	require.NoError(t, config.Load(&cfg, config.WithSources(
+		New(&cfg, []string{"--hosts=[::1]:80,[::2]:80", "--ports=80,443", "--labels=a:b;c:d"}),
+	)))
+	assert.Equal(t, []string{"[::1]:80", "[::2]:80"}, cfg.Hosts)
+	assert.Equal(t, []int{80, 443}, cfg.Ports)
+	assert.Equal(t, map[string]string{"a": "b", "c": "d"}, cfg.Labels)
+
+	var output bytes.Buffer
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.SetOutput(&output)
+
+	_, err := New(&cfg, []string{"--ports=80,http"}, WithFlagSet(flagSet))()
+	require.Error(t, err)
+	assert.Contains(t, output.String(), "ports")
+}
+
+func Test_kebabCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Name":          "name",
+		"ListenAddress": "listen-address",
+		"HTTP":          "http",
+		"HTTPServer":    "http-server",
+		"ServerHTTP":    "server-http",
+		"TLSCertFile":   "tls-cert-file",
+		"Port2":         "port2",
+	} {
+		assert.Equal(t, expected, kebabCase(name), name)
+	}
+}
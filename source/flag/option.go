@@ -0,0 +1,15 @@
+package sourceflag
+
+import (
+	"flag"
+)
+
+// Option defines the function signature to apply options.
+type Option func(f *Flag)
+
+// WithFlagSet registers flags on the provided flag set instead of a
+// new one, which allows to define other flags, or to change the way
+// errors are handled (flag.ContinueOnError by default).
+func WithFlagSet(flagSet *flag.FlagSet) Option {
+	return func(f *Flag) { f.flagSet = flagSet }
+}
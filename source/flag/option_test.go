@@ -0,0 +1,16 @@
+package sourceflag
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WithFlagSet(t *testing.T) {
+	var flagSet = flag.NewFlagSet("test", flag.ContinueOnError)
+
+	f := newFlag(t, nil, WithFlagSet(flagSet))
+	assert.Equal(t, flagSet, f.flagSet)
+	assert.NotNil(t, flagSet.Lookup("http.listen-address"))
+}
@@ -0,0 +1,110 @@
+// Package mapsource sources configuration from an in-memory map.
+package mapsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/krostar/config"
+	"github.com/krostar/config/trivialerr"
+)
+
+// Map implements config.Source to fetch values from a map
+// indexed by config tree paths, like "http.listenaddress".
+type Map struct {
+	values map[string]interface{}
+}
+
+// New returns a new map source. Keys are config tree paths, case insensitive.
+// String values are parsed the same way the env source parses them (see
+// config.InitializeNewValueOfTypeWithString), while any other value is
+// converted through json (see config.InitializeNewValueOfTypeWithJSON).
+// Structs given as a whole are decoded onto their current value, which
+// means fields absent from the map value are kept.
+func New(values map[string]interface{}) config.SourceCreationFunc {
+	return func() (config.Source, error) {
+		m := Map{values: make(map[string]interface{}, len(values))}
+
+		for treePath, value := range values {
+			m.values[strings.ToLower(treePath)] = value
+		}
+
+		return &m, nil
+	}
+}
+
+// Name implements config.Source interface.
+func (m *Map) Name() string { return "map" }
+
+// DescribeKey implements config.SourceKeyDescriber interface.
+func (m *Map) DescribeKey(treePath string) string { return treePath }
+
+// SetsStructValue implements config.SourceSetStructValue interface,
+// which allows to set a whole struct from a single map value.
+func (m *Map) SetsStructValue() bool { return true }
+
+// ListKeys implements config.SourceKeyLister interface. It returns the part that
+// directly follows the tree path in any key of the map: given "servers.0.host"
+// and "servers.1.host", ListKeys("servers") returns "0" and "1".
+func (m *Map) ListKeys(treePath string) ([]string, error) {
+	var paths = make([]string, 0, len(m.values))
+	for path := range m.values {
+		paths = append(paths, path)
+	}
+
+	return config.ListKeysOfPaths(treePath, ".", paths), nil
+}
+
+// SetValueFromConfigTreePath implements config.SourceSetValueFromConfigTreePath interface.
+func (m *Map) SetValueFromConfigTreePath(v *reflect.Value, treePath string) (bool, error) {
+	return m.SetValueFromConfigTreePathContext(context.Background(), v, treePath)
+}
+
+// SetValueFromConfigTreePathContext implements config.SourceSetValueFromConfigTreePathContext.
+// Strings given for slices, arrays and maps are read from separator-delimited values,
+// like environment variables.
+func (m *Map) SetValueFromConfigTreePathContext(ctx context.Context, v *reflect.Value, treePath string) (bool, error) {
+	value, exists := m.values[treePath]
+	if !exists {
+		return false, fmt.Errorf("map does not contain key %s: %w", treePath, trivialerr.ErrNotFound)
+	}
+
+	var (
+		newV          *reflect.Value
+		err           error
+		str, isString = value.(string)
+		isStruct      = v.Kind() == reflect.Struct && !config.HasStringDecoder(v.Type())
+	)
+
+	switch {
+	case isString && config.IsDelimitedType(v.Type()):
+		field, _ := config.StructFieldFromContext(ctx)
+		sep, kvsep := config.DelimitersOfStructField(field)
+		newV, err = config.InitializeNewValueOfTypeWithDelimitedString(v.Type(), str, sep, kvsep)
+	case isString && !isStruct:
+		newV, err = config.InitializeNewValueOfTypeWithString(v.Type(), str)
+	default:
+		// strings given for structs are their json representation
+		raw := []byte(str)
+		if !isString {
+			if raw, err = json.Marshal(value); err != nil {
+				return false, fmt.Errorf("unable to convert value of key %s to json: %w", treePath, err)
+			}
+		}
+
+		if isStruct {
+			newV, err = config.InitializeNewValueOfStructWithJSON(*v, raw)
+		} else {
+			newV, err = config.InitializeNewValueOfTypeWithJSON(v.Type(), raw)
+		}
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("unable to create a new value for key %s: %w", treePath, err)
+	}
+
+	return config.SetNewValue(v, newV)
+}
@@ -0,0 +1,191 @@
+package mapsource
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/krostar/config"
+	"github.com/krostar/config/configtest"
+	"github.com/krostar/config/trivialerr"
+)
+
+func newMap(t *testing.T, values map[string]interface{}) *Map {
+	m, err := New(values)()
+	require.NoError(t, err)
+	return m.(*Map)
+}
+
+func TestMap_conformance(t *testing.T) {
+	configtest.RunSourceSuite(t, func(t *testing.T, values map[string]interface{}) config.Source {
+		var flat = make(map[string]interface{})
+		for treePath, value := range configtest.Flatten(values) {
+			flat[treePath] = value
+		}
+		return newMap(t, flat)
+	})
+}
+
+func Test_New(t *testing.T) {
+	m := newMap(t, map[string]interface{}{"HTTP.ListenAddress": ":9000"})
+	assert.Equal(t, map[string]interface{}{"http.listenaddress": ":9000"}, m.values)
+	assert.Equal(t, "map", m.Name())
+	assert.Equal(t, "http.listenaddress", m.DescribeKey("http.listenaddress"))
+	assert.True(t, m.SetsStructValue())
+}
+
+func TestMap_ListKeys(t *testing.T) {
+	m := newMap(t, map[string]interface{}{
+		"servers.1.host": "b",
+		"servers.0.host": "a",
+		"servers.0.port": 1,
+		"serversother":   "c",
+	})
+
+	keys, err := m.ListKeys("servers")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "1"}, keys)
+
+	keys, err = m.ListKeys("nothing")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestMap_SetValueFromConfigTreePath(t *testing.T) {
+	var tests = map[string]struct {
+		value           interface{}
+		expected        interface{}
+		expectedFailure bool
+	}{
+		"string": {
+			value:    "hello",
+			expected: "hello",
+		}, "string parsed": {
+			value:    "3s",
+			expected: 3 * time.Second,
+		}, "typed": {
+			value:    42,
+			expected: 42,
+		}, "typed duration": {
+			value:    time.Minute,
+			expected: time.Minute,
+		}, "typed slice": {
+			value:    []string{"a", "b"},
+			expected: []string{"a", "b"},
+		}, "typed struct": {
+			value:    map[string]interface{}{"host": "db"},
+			expected: struct{ Host string }{Host: "db"},
+		}, "invalid string": {
+			value:           "not a number",
+			expected:        0,
+			expectedFailure: true,
+		}, "invalid typed": {
+			value:           true,
+			expected:        0,
+			expectedFailure: true,
+		}, "not convertible to json": {
+			value:           func() {},
+			expected:        0,
+			expectedFailure: true,
+		},
+	}
+
+	for name, test := range tests {
+		var test = test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				m = newMap(t, map[string]interface{}{"key": test.value})
+				v = reflect.New(reflect.TypeOf(test.expected)).Elem()
+			)
+
+			isSet, err := m.SetValueFromConfigTreePath(&v, "key")
+			if test.expectedFailure {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, isSet)
+			assert.Equal(t, test.expected, v.Interface())
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		var v = reflect.New(reflect.TypeOf("")).Elem()
+		_, err := newMap(t, nil).SetValueFromConfigTreePath(&v, "key")
+		require.Error(t, err)
+		assert.True(t, trivialerr.IsTrivial(err))
+		assert.True(t, errors.Is(err, trivialerr.ErrNotFound))
+	})
+}
+
+func Test_Load_overrides(t *testing.T) {
+	type cfg struct {
+		HTTP struct {
+			ListenAddress string
+			Timeout       time.Duration
+		}
+		Servers []struct{ Host string }
+	}
+
+	var c cfg
+	c.HTTP.ListenAddress = ":8080"
+
+	require.NoError(t, config.Load(&c, config.WithSources(New(map[string]interface{}{
+		"http.timeout":   "3s",
+		"servers.1.host": "b",
+	}))))
+	assert.Equal(t, ":8080", c.HTTP.ListenAddress)
+	assert.Equal(t, 3*time.Second, c.HTTP.Timeout)
+	assert.Len(t, c.Servers, 2)
+	assert.Equal(t, "b", c.Servers[1].Host)
+}
+
+func Test_Load_partialStruct(t *testing.T) {
+	type cfg struct {
+		Database struct {
+			Host string
+			Port int `default:"5432"`
+		}
+	}
+
+	var c cfg
+
+	require.NoError(t, config.Load(&c, config.WithSources(New(map[string]interface{}{
+		"database": map[string]interface{}{"host": "db"},
+	}))))
+	assert.Equal(t, "db", c.Database.Host)
+	assert.Equal(t, 5432, c.Database.Port)
+}
+
+func Test_Load_delimited(t *testing.T) {
+	type cfg struct {
+		Hosts  []string
+		Ports  []int `sep:";"`
+		Labels map[string]string
+		Tags   []string
+	}
+
+	var c cfg
+
+	// strings are delimited, other values are used as is
+	require.NoError(t, config.Load(&c, config.WithSources(New(map[string]interface{}{
+		"hosts":  "a,b",
+		"ports":  "80;443",
+		"labels": "env=prod,team=core",
+		"tags":   []string{"c,d"},
+	}))))
+	assert.Equal(t, cfg{
+		Hosts:  []string{"a", "b"},
+		Ports:  []int{80, 443},
+		Labels: map[string]string{"env": "prod", "team": "core"},
+		Tags:   []string{"c,d"},
+	}, c)
+}
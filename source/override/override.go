@@ -0,0 +1,170 @@
+// Package sourceoverride sources configuration from a list of
+// overrides, like "database.pool.size=20", usually given through
+// command-line flags.
+package sourceoverride
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/krostar/config"
+	"github.com/krostar/config/trivialerr"
+)
+
+// Override implements config.Source to fetch values from
+// overrides indexed by config tree paths.
+type Override struct {
+	values map[string]string
+}
+
+// New returns a new override source. Each override has the form path=value,
+// where path is a config tree path, and value is parsed the same way the env
+// source parses values (see config.InitializeNewValueOfTypeWithString).
+// Paths must exist in the config tree of cfg, either as a leaf, or as an
+// element of a slice, array or map leaf followed by a valid path of the element
+// (like "servers.0.host"), otherwise an error listing the valid paths is returned.
+func New(cfg interface{}, overrides []string) config.SourceCreationFunc {
+	return func() (config.Source, error) {
+		var (
+			o = Override{values: make(map[string]string, len(overrides))}
+
+			typ     = reflect.TypeOf(cfg)
+			unknown []string
+		)
+
+		for _, override := range overrides {
+			kv := strings.SplitN(override, "=", 2)
+			if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+				return nil, fmt.Errorf("override %q is not of the form path=value", override)
+			}
+
+			treePath := strings.ToLower(strings.TrimSpace(kv[0]))
+			if !isValidPath(typ, treePath) {
+				unknown = append(unknown, treePath)
+				continue
+			}
+
+			o.values[treePath] = kv[1]
+		}
+
+		if len(unknown) > 0 {
+			var valid []string
+			config.WalkTreeLeaves(typ, func(leaf config.TreeLeaf) {
+				valid = append(valid, leaf.TreePath)
+			})
+			sort.Strings(valid)
+
+			return nil, fmt.Errorf("unknown config tree paths %s, valid paths are %s",
+				strings.Join(unknown, ", "), strings.Join(valid, ", "),
+			)
+		}
+
+		return &o, nil
+	}
+}
+
+// isValidPath returns true if the tree path is a leaf of the config tree of typ, or
+// an index or a key of a slice, array or map leaf, followed by nothing or by a
+// valid tree path of the element type (which may itself be a collection).
+func isValidPath(typ reflect.Type, treePath string) bool {
+	var valid bool
+
+	config.WalkTreeLeaves(typ, func(leaf config.TreeLeaf) {
+		if valid || leaf.TreePath == treePath {
+			valid = true
+			return
+		}
+
+		switch leaf.Type.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+		default:
+			return
+		}
+
+		var elementPath = treePath
+		if leaf.TreePath != "" {
+			if !strings.HasPrefix(treePath, leaf.TreePath+".") {
+				return
+			}
+			elementPath = treePath[len(leaf.TreePath)+1:]
+		}
+
+		// the first part is the index or the key, the rest belongs to the element
+		parts := strings.SplitN(elementPath, ".", 2)
+		valid = parts[0] != "" && (len(parts) == 1 || isValidPath(leaf.Type.Elem(), parts[1]))
+	})
+
+	return valid
+}
+
+// Name implements config.Source interface.
+func (o *Override) Name() string { return "override" }
+
+// ListKeys implements config.SourceKeyLister interface. It returns the part
+// that directly follows the tree path in any overridden path: given
+// "servers.0.host" and "servers.1.host", ListKeys("servers") returns "0" and "1".
+func (o *Override) ListKeys(treePath string) ([]string, error) {
+	var paths = make([]string, 0, len(o.values))
+	for path := range o.values {
+		paths = append(paths, path)
+	}
+
+	return config.ListKeysOfPaths(treePath, ".", paths), nil
+}
+
+// SetValueFromConfigTreePath implements config.SourceSetValueFromConfigTreePath interface.
+func (o *Override) SetValueFromConfigTreePath(v *reflect.Value, treePath string) (bool, error) {
+	return o.SetValueFromConfigTreePathContext(context.Background(), v, treePath)
+}
+
+// SetValueFromConfigTreePathContext implements config.SourceSetValueFromConfigTreePathContext.
+// Slices, arrays and maps are read from separator-delimited values, like environment variables.
+func (o *Override) SetValueFromConfigTreePathContext(ctx context.Context, v *reflect.Value, treePath string) (bool, error) {
+	raw, exists := o.values[treePath]
+	if !exists {
+		return false, fmt.Errorf("key %s is not overridden: %w", treePath, trivialerr.ErrNotFound)
+	}
+
+	var (
+		newV *reflect.Value
+		err  error
+	)
+
+	if config.IsDelimitedType(v.Type()) {
+		field, _ := config.StructFieldFromContext(ctx)
+		sep, kvsep := config.DelimitersOfStructField(field)
+		newV, err = config.InitializeNewValueOfTypeWithDelimitedString(v.Type(), raw, sep, kvsep)
+	} else {
+		newV, err = config.InitializeNewValueOfTypeWithString(v.Type(), raw)
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to initialize new value from %q: %w", raw, err)
+	}
+
+	return config.SetNewValue(v, newV)
+}
+
+// Overrides implements flag.Value to gather overrides
+// from a repeated flag, like -o a.b=c -o d=e.
+type Overrides []string
+
+// String implements flag.Value interface.
+func (o *Overrides) String() string {
+	if o == nil {
+		return ""
+	}
+	return strings.Join(*o, ", ")
+}
+
+// Set implements flag.Value interface.
+func (o *Overrides) Set(override string) error {
+	if !strings.Contains(override, "=") {
+		return errors.New("override must be of the form path=value")
+	}
+	*o = append(*o, override)
+	return nil
+}
@@ -0,0 +1,140 @@
+package sourceoverride
+
+import (
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/krostar/config"
+	"github.com/krostar/config/trivialerr"
+)
+
+type overrideCfg struct {
+	Database struct {
+		Pool struct {
+			Size int
+		}
+	}
+	HTTP struct {
+		Debug   bool
+		Timeout time.Duration
+	}
+	Servers []struct{ Host string }
+	Labels  map[string]string
+	Matrix  [][]int
+}
+
+func Test_New(t *testing.T) {
+	var tests = map[string]struct {
+		overrides       []string
+		expectedValues  map[string]string
+		expectedFailure string
+	}{
+		"no overrides": {
+			expectedValues: map[string]string{},
+		}, "leaves and collections elements": {
+			overrides: []string{"Database.Pool.Size=20", " http.debug =true", "servers.1.host=b", "labels.env=a=b"},
+			expectedValues: map[string]string{
+				"database.pool.size": "20",
+				"http.debug":         "true",
+				"servers.1.host":     "b",
+				"labels.env":         "a=b",
+			},
+		}, "malformed": {
+			overrides:       []string{"http.debug"},
+			expectedFailure: `override "http.debug" is not of the form path=value`,
+		}, "empty path": {
+			overrides:       []string{"=true"},
+			expectedFailure: `override "=true" is not of the form path=value`,
+		}, "unknown paths": {
+			overrides: []string{"database.pool=20", "http.debug=true", "unknown=1"},
+			expectedFailure: "unknown config tree paths database.pool, unknown, valid paths are " +
+				"database.pool.size, http.debug, http.timeout, labels, matrix, servers",
+		}, "nested collections elements": {
+			overrides:      []string{"matrix.0.1=2", "servers.0={}"},
+			expectedValues: map[string]string{"matrix.0.1": "2", "servers.0": "{}"},
+		}, "unknown paths of collections elements": {
+			overrides: []string{"servers.0.hots=x", "labels.env.name=x", "matrix.0.1.2=x", "servers..host=x"},
+			expectedFailure: "unknown config tree paths servers.0.hots, labels.env.name, matrix.0.1.2, servers..host, " +
+				"valid paths are database.pool.size, http.debug, http.timeout, labels, matrix, servers",
+		},
+	}
+
+	for name, test := range tests {
+		var test = test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			o, err := New(&overrideCfg{}, test.overrides)()
+			if test.expectedFailure != "" {
+				require.EqualError(t, err, test.expectedFailure)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedValues, o.(*Override).values)
+		})
+	}
+}
+
+func TestOverride_SetValueFromConfigTreePath(t *testing.T) {
+	o := Override{values: map[string]string{"size": "20", "invalid": "twenty"}}
+
+	var v = reflect.New(reflect.TypeOf(0)).Elem()
+	isSet, err := o.SetValueFromConfigTreePath(&v, "size")
+	require.NoError(t, err)
+	assert.True(t, isSet)
+	assert.Equal(t, 20, v.Interface())
+
+	_, err = o.SetValueFromConfigTreePath(&v, "invalid")
+	require.Error(t, err)
+	assert.False(t, trivialerr.IsTrivial(err))
+
+	_, err = o.SetValueFromConfigTreePath(&v, "unknown")
+	require.Error(t, err)
+	assert.True(t, trivialerr.IsTrivial(err))
+}
+
+func Test_Load(t *testing.T) {
+	var (
+		overrides Overrides
+		flagSet   = flag.NewFlagSet("test", flag.ContinueOnError)
+	)
+
+	flagSet.SetOutput(ioutil.Discard)
+	flagSet.Var(&overrides, "o", "override configuration")
+	require.NoError(t, flagSet.Parse([]string{
+		"-o", "database.pool.size=20", "-o", "http.debug=true", "-o", "servers.1.host=b", "-o", "labels.env=prod",
+	}))
+	require.Error(t, flagSet.Parse([]string{"-o", "database.pool.size"}))
+	assert.Equal(t, "database.pool.size=20, http.debug=true, servers.1.host=b, labels.env=prod", overrides.String())
+
+	var cfg overrideCfg
+	cfg.HTTP.Timeout = time.Second
+
+	require.NoError(t, config.Load(&cfg, config.WithSources(New(&cfg, overrides))))
+	assert.Equal(t, 20, cfg.Database.Pool.Size)
+	assert.True(t, cfg.HTTP.Debug)
+	assert.Equal(t, time.Second, cfg.HTTP.Timeout)
+	assert.Equal(t, []struct{ Host string }{{}, {Host: "b"}}, cfg.Servers)
+	assert.Equal(t, map[string]string{"env": "prod"}, cfg.Labels)
+}
+
+func Test_Load_delimited(t *testing.T) {
+	var cfg struct {
+		Hosts  []string
+		Ports  []int `sep:";"`
+		Labels map[string]string
+	}
+
+	require.NoError(t, config.Load(&cfg, config.WithSources(New(&cfg, Overrides{
+		"hosts=a,b", "ports=80;443", "labels=env=prod,team=core",
+	}))))
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+	assert.Equal(t, []int{80, 443}, cfg.Ports)
+	assert.Equal(t, map[string]string{"env": "prod", "team": "core"}, cfg.Labels)
+}
@@ -0,0 +1,21 @@
+package sourcesecrets
+
+import (
+	"github.com/spf13/afero"
+)
+
+// Option defines the function signature to apply options.
+type Option func(s *Secrets)
+
+// WithFileName sets the function that returns the name of the file of a
+// config tree path, which is the tree path itself by default. EnvStyleFileName
+// can be used for directories with files like DATABASE_PASSWORD.
+func WithFileName(fileName func(treePath string) string) Option {
+	return func(s *Secrets) { s.fileName = fileName }
+}
+
+// WithFs sets the filesystem secrets are read from,
+// which is the read-only os filesystem by default.
+func WithFs(fs afero.Fs) Option {
+	return func(s *Secrets) { s.fs = fs }
+}
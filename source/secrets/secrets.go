@@ -0,0 +1,145 @@
+// Package sourcesecrets sources configuration from a secrets directory,
+// where each file provides the value of one key, like Kubernetes secret
+// volumes, Docker secrets (/run/secrets), or systemd credentials
+// ($CREDENTIALS_DIRECTORY).
+package sourcesecrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/krostar/config"
+	"github.com/krostar/config/trivialerr"
+)
+
+// Secrets implements config.Source to fetch values
+// from the files of a secrets directory.
+type Secrets struct {
+	fs       afero.Fs
+	dir      string
+	fileName func(treePath string) string
+}
+
+// New returns a new secrets source. The value of a config tree path is the
+// content of the file of dir named after the tree path, like "database.password",
+// without its trailing newline if any. Symlinks are followed, as long as they
+// lead to a file inside dir; as they are resolved through the os, they are only
+// supported by filesystems that use os paths as is, like the default one.
+func New(dir string, opts ...Option) config.SourceCreationFunc {
+	return func() (config.Source, error) {
+		s := Secrets{
+			fs:       afero.NewReadOnlyFs(afero.NewOsFs()),
+			dir:      dir,
+			fileName: func(treePath string) string { return treePath },
+		}
+
+		for _, opt := range opts {
+			opt(&s)
+		}
+
+		return &s, nil
+	}
+}
+
+// EnvStyleFileName returns the file name of the tree path in
+// uppercase, with underscores instead of dots, like DATABASE_PASSWORD.
+func EnvStyleFileName(treePath string) string {
+	return strings.ToUpper(strings.ReplaceAll(treePath, ".", "_"))
+}
+
+// Name implements config.Source interface.
+func (s *Secrets) Name() string { return "secrets" }
+
+// DescribeKey implements config.SourceKeyDescriber interface.
+func (s *Secrets) DescribeKey(treePath string) string {
+	return filepath.Join(s.dir, s.fileName(treePath))
+}
+
+// SetValueFromConfigTreePath implements config.SourceSetValueFromConfigTreePath interface.
+// As values are secrets, they are never part of returned errors.
+func (s *Secrets) SetValueFromConfigTreePath(v *reflect.Value, treePath string) (bool, error) {
+	secret, err := s.read(s.fileName(treePath))
+	if err != nil {
+		return false, err
+	}
+
+	newV, err := config.InitializeNewValueOfTypeWithString(v.Type(), secret)
+	if err != nil {
+		// decoding errors usually contain the value, which must not be leaked
+		return false, fmt.Errorf("unable to decode secret of key %s: %w", treePath, config.RedactDecodeError(err))
+	}
+
+	return config.SetNewValue(v, newV)
+}
+
+func (s *Secrets) read(fileName string) (string, error) {
+	if fileName == "" || strings.ContainsRune(fileName, filepath.Separator) {
+		return "", fmt.Errorf("invalid secret file name %q", fileName)
+	}
+
+	path, err := s.resolve(filepath.Join(s.dir, fileName))
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("secret %s does not exist: %w", fileName, trivialerr.ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve secret %s: %w", fileName, err)
+	}
+
+	info, err := s.fs.Stat(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("secret %s does not exist: %w", fileName, trivialerr.ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to stat secret %s: %w", fileName, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("secret %s is a directory: %w", fileName, trivialerr.ErrNotFound)
+	}
+
+	content, err := afero.ReadFile(s.fs, path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read secret %s: %w", fileName, err)
+	}
+
+	return config.TrimTrailingNewline(string(content)), nil
+}
+
+// resolve returns the path the symlink at path leads to, which must be
+// inside the secrets directory, or path itself if it is not a symlink.
+// Only filesystems that implement afero.Lstater can tell about symlinks,
+// which are resolved through the os.
+func (s *Secrets) resolve(path string) (string, error) {
+	lstater, ok := s.fs.(afero.Lstater)
+	if !ok {
+		return path, nil
+	}
+
+	info, lstatCalled, err := lstater.LstatIfPossible(path)
+	if err != nil {
+		return "", err
+	}
+	if !lstatCalled || info.Mode()&os.ModeSymlink == 0 {
+		return path, nil
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := filepath.EvalSymlinks(s.dir)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve secrets directory: %w", err)
+	}
+	if !strings.HasPrefix(resolved, dir+string(filepath.Separator)) {
+		return "", errors.New("it links outside of the secrets directory")
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,214 @@
+package sourcesecrets
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/krostar/config"
+	"github.com/krostar/config/configtest"
+	"github.com/krostar/config/trivialerr"
+)
+
+func newSecrets(t *testing.T, dir string, opts ...Option) *Secrets {
+	s, err := New(dir, opts...)()
+	require.NoError(t, err)
+	return s.(*Secrets)
+}
+
+// secretsDir creates a temporary directory with the provided files,
+// removed once the test and its sub tests are done.
+func secretsDir(t *testing.T, files map[string]string) string {
+	root, err := ioutil.TempDir("", "sourcesecrets")
+	require.NoError(t, err)
+
+	var dir = filepath.Join(root, "secrets")
+	require.NoError(t, os.Mkdir(dir, 0700))
+
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	t.Cleanup(func() { _ = os.RemoveAll(root) })
+
+	return dir
+}
+
+func TestSecrets_conformance(t *testing.T) {
+	configtest.RunSourceSuite(t, func(t *testing.T, values map[string]interface{}) config.Source {
+		return newSecrets(t, secretsDir(t, configtest.Flatten(values)))
+	})
+}
+
+func Test_New(t *testing.T) {
+	s := newSecrets(t, "/run/secrets")
+	assert.Equal(t, "secrets", s.Name())
+	assert.Equal(t, "/run/secrets/database.password", s.DescribeKey("database.password"))
+
+	s = newSecrets(t, "/run/secrets", WithFileName(EnvStyleFileName))
+	assert.Equal(t, "/run/secrets/DATABASE_PASSWORD", s.DescribeKey("database.password"))
+}
+
+func TestSecrets_SetValueFromConfigTreePath(t *testing.T) {
+	dir := secretsDir(t, map[string]string{
+		"password":          "secret\n",
+		"windows":           "secret\r\n",
+		"lines":             "line1\nline2\n\n",
+		"port":              "not a number",
+		"DATABASE_PASSWORD": "env style",
+		"..data.password":   "kubernetes",
+	})
+
+	var outside = filepath.Join(filepath.Dir(dir), "outside")
+	require.NoError(t, ioutil.WriteFile(outside, []byte("outside"), 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "directory"), 0700))
+	require.NoError(t, os.Symlink("..data.password", filepath.Join(dir, "linked")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "escaping")))
+	require.NoError(t, os.Symlink("../outside", filepath.Join(dir, "relativeescaping")))
+	require.NoError(t, os.Symlink("missing", filepath.Join(dir, "dangling")))
+
+	var tests = map[string]struct {
+		treePath               string
+		opts                   []Option
+		typ                    reflect.Type
+		expected               interface{}
+		expectedFailure        bool
+		expectedTrivialFailure bool
+	}{
+		"trailing newline is trimmed": {
+			treePath: "password",
+			expected: "secret",
+		}, "trailing windows newline is trimmed": {
+			treePath: "windows",
+			expected: "secret",
+		}, "only one trailing newline is trimmed": {
+			treePath: "lines",
+			expected: "line1\nline2\n",
+		}, "env style file name": {
+			treePath: "database.password",
+			opts:     []Option{WithFileName(EnvStyleFileName)},
+			expected: "env style",
+		}, "symlink inside the directory": {
+			treePath: "linked",
+			expected: "kubernetes",
+		}, "missing": {
+			treePath:               "missing",
+			expectedFailure:        true,
+			expectedTrivialFailure: true,
+		}, "dangling symlink": {
+			treePath:               "dangling",
+			expectedFailure:        true,
+			expectedTrivialFailure: true,
+		}, "directory": {
+			treePath:               "directory",
+			expectedFailure:        true,
+			expectedTrivialFailure: true,
+		}, "symlink outside the directory": {
+			treePath:        "escaping",
+			expectedFailure: true,
+		}, "relative symlink outside the directory": {
+			treePath:        "relativeescaping",
+			expectedFailure: true,
+		}, "path traversal": {
+			treePath:        "database.password",
+			opts:            []Option{WithFileName(func(string) string { return "../outside" })},
+			expectedFailure: true,
+		}, "empty file name": {
+			treePath:        "database.password",
+			opts:            []Option{WithFileName(func(string) string { return "" })},
+			expectedFailure: true,
+		}, "invalid value": {
+			treePath:        "port",
+			typ:             reflect.TypeOf(0),
+			expectedFailure: true,
+		},
+	}
+
+	for name, test := range tests {
+		var test = test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var typ = test.typ
+			if typ == nil {
+				typ = reflect.TypeOf("")
+			}
+			v := reflect.New(typ).Elem()
+
+			isSet, err := newSecrets(t, dir, test.opts...).SetValueFromConfigTreePath(&v, test.treePath)
+			if test.expectedFailure {
+				require.Error(t, err)
+				assert.Equal(t, test.expectedTrivialFailure, trivialerr.IsTrivial(err))
+				assert.Equal(t, test.expectedTrivialFailure, errors.Is(err, trivialerr.ErrNotFound))
+				assert.NotContains(t, err.Error(), "not a number", "secret values must not be leaked")
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, isSet)
+			assert.Equal(t, test.expected, v.Interface())
+		})
+	}
+}
+
+func TestSecrets_SetValueFromConfigTreePath_decodeError(t *testing.T) {
+	var (
+		dir = secretsDir(t, map[string]string{"port": "not a number"})
+		v   = reflect.New(reflect.TypeOf(0)).Elem()
+	)
+
+	_, err := newSecrets(t, dir).SetValueFromConfigTreePath(&v, "port")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "not a number", "secret values must not be leaked")
+
+	var decodeErr *config.DecodeError
+	require.True(t, errors.As(err, &decodeErr), "the cause should be kept")
+	assert.Equal(t, reflect.TypeOf(0), decodeErr.Type)
+	assert.Empty(t, decodeErr.RawValue)
+}
+
+func Test_WithFs(t *testing.T) {
+	var fs = afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/run/secrets/password", []byte("secret\n"), 0600))
+	require.NoError(t, fs.Mkdir("/run/secrets/directory", 0700))
+
+	s := newSecrets(t, "/run/secrets", WithFs(fs))
+
+	v := reflect.New(reflect.TypeOf("")).Elem()
+	isSet, err := s.SetValueFromConfigTreePath(&v, "password")
+	require.NoError(t, err)
+	assert.True(t, isSet)
+	assert.Equal(t, "secret", v.Interface())
+
+	for _, treePath := range []string{"missing", "directory"} {
+		_, err = s.SetValueFromConfigTreePath(&v, treePath)
+		require.Error(t, err)
+		assert.True(t, trivialerr.IsTrivial(err))
+	}
+}
+
+func Test_Load(t *testing.T) {
+	type cfg struct {
+		Database struct {
+			User     string
+			Password string `cfg:",required"`
+		}
+	}
+
+	dir := secretsDir(t, map[string]string{"database.password": "secret\n"})
+
+	var c cfg
+	c.Database.User = "user"
+	require.NoError(t, config.Load(&c, config.WithSources(New(dir))))
+	assert.Equal(t, "user", c.Database.User)
+	assert.Equal(t, "secret", c.Database.Password)
+
+	err := config.Load(&c, config.WithSources(New(filepath.Join(dir, "missing"))))
+	require.NoError(t, err, "missing secrets of already set fields are not errors")
+}
@@ -1,14 +1,18 @@
 package config
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
-	"github.com/krostar/config/internal/trivialerr"
+	"github.com/krostar/config/trivialerr"
 )
 
 type stubSourceThatUseReflection map[string]string
@@ -41,6 +45,30 @@ func (s stubSourceThatUseReflection) SetValueFromConfigTreePath(v *reflect.Value
 	return SetNewValue(v, newV)
 }
 
+// stubSourceThatListKeys lists keys of the underlying stub based on its config tree paths.
+type stubSourceThatListKeys struct {
+	stubSourceThatUseReflection
+	err error
+}
+
+func (s stubSourceThatListKeys) Name() string { return "stub list keys" }
+
+func (s stubSourceThatListKeys) ListKeys(treePath string) ([]string, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	var keys []string
+	for key := range s.stubSourceThatUseReflection {
+		if strings.HasPrefix(key, treePath+".") {
+			keys = append(keys, strings.SplitN(strings.TrimPrefix(key, treePath+"."), ".", 2)[0])
+		}
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
 type stubSourceThatUnmarshal int
 
 func (s stubSourceThatUnmarshal) Name() string { return "stub unmarshal" }
@@ -58,6 +86,30 @@ func (s stubSourceThatUnmarshal) Unmarshal(interface{}) error {
 	return err
 }
 
+type stubSourceThatUnmarshalWithContext struct{ ctxValue interface{} }
+
+func (s *stubSourceThatUnmarshalWithContext) Name() string { return "stub unmarshal with context" }
+
+func (s *stubSourceThatUnmarshalWithContext) UnmarshalContext(ctx context.Context, _ interface{}) error {
+	s.ctxValue = ctx.Value(stubContextKey{})
+	return ctx.Err()
+}
+
+type stubSourceThatUseReflectionWithContext struct{ stubSourceThatUseReflection }
+
+func (s stubSourceThatUseReflectionWithContext) Name() string { return "stub reflect with context" }
+
+func (s stubSourceThatUseReflectionWithContext) SetValueFromConfigTreePathContext(
+	ctx context.Context, v *reflect.Value, name string,
+) (bool, error) {
+	if ctx.Value(stubContextKey{}) == nil {
+		return false, errors.New("context has not been forwarded")
+	}
+	return s.SetValueFromConfigTreePath(v, name)
+}
+
+type stubContextKey struct{}
+
 type dumbSource struct{}
 
 func (dumbSource) Name() string { return "dumb" }
@@ -95,3 +147,114 @@ func Test_appendConfigTreePath(t *testing.T) {
 		})
 	}
 }
+
+type stubSourceThatRecordFields map[string]reflect.StructField
+
+func (s stubSourceThatRecordFields) Name() string { return "stub record fields" }
+
+func (s stubSourceThatRecordFields) SetValueFromConfigTreePathContext(
+	ctx context.Context, _ *reflect.Value, name string,
+) (bool, error) {
+	if field, ok := StructFieldFromContext(ctx); ok {
+		s[name] = field
+	}
+	return false, nil
+}
+
+func Test_StructFieldFromContext(t *testing.T) {
+	type (
+		nested struct {
+			Nested string `tag:"nested"`
+		}
+		cfg struct {
+			Str     string  `tag:"str"`
+			Pointer *nested `tag:"pointer"`
+		}
+	)
+
+	var source = make(stubSourceThatRecordFields)
+	require.NoError(t, Load(&cfg{}, WithRawSources(source)))
+	require.Len(t, source, 2)
+	assert.Equal(t, "str", source["str"].Tag.Get("tag"))
+	assert.Equal(t, "nested", source["pointer.nested"].Tag.Get("tag"))
+
+	var i int
+	source = make(stubSourceThatRecordFields)
+	require.NoError(t, Load(&i, WithRawSources(source)))
+	assert.Empty(t, source)
+
+	_, ok := StructFieldFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func Test_TrimTrailingNewline(t *testing.T) {
+	for content, expected := range map[string]string{
+		"":           "",
+		"secret":     "secret",
+		"secret\n":   "secret",
+		"secret\r\n": "secret",
+		"secret\n\n": "secret\n",
+		"sec\nret\n": "sec\nret",
+		"secret\r":   "secret\r",
+	} {
+		assert.Equal(t, expected, TrimTrailingNewline(content), "content %q", content)
+	}
+}
+
+func Test_ListKeysOfPaths(t *testing.T) {
+	var paths = []string{"servers.1.host", "servers.0.host", "servers.0.port", "serversother", "servers.", "servers"}
+
+	assert.Equal(t, []string{"0", "1"}, ListKeysOfPaths("servers", ".", paths))
+	assert.Empty(t, ListKeysOfPaths("nothing", ".", paths))
+	assert.Equal(t, []string{"a", "b"}, ListKeysOfPaths("map", "_", []string{"map_b", "map_a_c", "map_a"}))
+}
+
+// stubSourceThatSetStructs also asks for structs as a whole.
+type stubSourceThatSetStructs struct{ stubSourceThatUseReflection }
+
+func (s stubSourceThatSetStructs) Name() string { return "stub set structs" }
+
+func (s stubSourceThatSetStructs) SetsStructValue() bool { return true }
+
+// stubSourceThatRefresh refreshes its values with refresh before each load.
+type stubSourceThatRefresh struct {
+	stubSourceThatUseReflection
+	refresh func(values stubSourceThatUseReflection) error
+}
+
+func (s stubSourceThatRefresh) Refresh(context.Context) error {
+	return s.refresh(s.stubSourceThatUseReflection)
+}
+
+func Test_SourceRefresher(t *testing.T) {
+	var (
+		loads  int
+		source = stubSourceThatRefresh{
+			stubSourceThatUseReflection: make(stubSourceThatUseReflection),
+			refresh: func(values stubSourceThatUseReflection) error {
+				loads++
+				values["name"] = fmt.Sprintf("load %d", loads)
+				return nil
+			},
+		}
+		cfg struct{ Name string }
+	)
+
+	require.NoError(t, Load(&cfg, WithRawSources(source)))
+	assert.Equal(t, "load 1", cfg.Name)
+	require.NoError(t, Load(&cfg, WithRawSources(source)))
+	assert.Equal(t, "load 2", cfg.Name)
+
+	// trivial errors skip the source, others fail the load
+	source.refresh = func(values stubSourceThatUseReflection) error {
+		values["name"] = "skipped"
+		return trivialerr.New("nothing to refresh")
+	}
+	require.NoError(t, Load(&cfg, WithRawSources(source)))
+	assert.Equal(t, "load 2", cfg.Name)
+
+	source.refresh = func(stubSourceThatUseReflection) error { return errors.New("boom") }
+	err := Load(&cfg, WithRawSources(source))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to refresh stub reflect: boom")
+}
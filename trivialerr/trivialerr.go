@@ -1,4 +1,26 @@
-// Package trivialerr helps to define and use trivial (not critical) errors.
+/*
+Package trivialerr helps to define and use trivial (not critical) errors.
+
+Sources use trivial errors to tell the loader that they simply have no value
+for the requested key, or nothing to load at all. The loader ignores trivial
+errors returned by Unmarshal and SetValueFromConfigTreePath methods, as well as
+by ListKeys, and continues the load as if nothing happened. Any other error
+aborts the load, or is aggregated with others if the loader is asked to.
+
+A source that does not find a key would typically do:
+
+	value, found := lookup(key)
+	if !found {
+		return false, fmt.Errorf("key %s: %w", key, trivialerr.ErrNotFound)
+	}
+
+and a source that may or may not consider a failure critical:
+
+	return trivialerr.WrapIf(s.strict, err)
+
+Trivial errors can be checked with IsTrivial, which looks through
+the whole chain of wrapped errors.
+*/
 package trivialerr
 
 import (
@@ -6,6 +28,10 @@ import (
 	"fmt"
 )
 
+// ErrNotFound is the trivial error sources should wrap
+// or return when they have no value for a key.
+var ErrNotFound = New("not found")
+
 // TrivialError defines the way a trivial error is defined.
 type TrivialError interface {
 	error
@@ -2,6 +2,7 @@ package trivialerr
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -45,3 +46,10 @@ func Test_IsTrivial(t *testing.T) {
 	assert.False(t, IsTrivial(originalErr))
 	assert.True(t, IsTrivial(trivialErr))
 }
+
+func Test_ErrNotFound(t *testing.T) {
+	err := fmt.Errorf("key %s: %w", "a.b", ErrNotFound)
+	assert.True(t, IsTrivial(err))
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.False(t, errors.Is(New("not found"), ErrNotFound))
+}
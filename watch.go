@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Store holds a configuration that can be atomically replaced.
+// It is safe for concurrent use.
+type Store struct {
+	value atomic.Value
+}
+
+// NewStore creates a new store that holds the provided configuration.
+// The configuration must be a non-nil pointer, and should not be
+// modified once given to the store.
+func NewStore(cfg interface{}) (*Store, error) {
+	var value = reflect.ValueOf(cfg)
+
+	if !value.IsValid() || value.Kind() != reflect.Ptr || value.IsNil() {
+		return nil, errors.New("cfg must be a non-nil pointer")
+	}
+
+	var s Store
+	s.value.Store(cfg)
+
+	return &s, nil
+}
+
+// Get returns the current configuration. The returned value has the
+// same type as the one provided to NewStore and should not be modified.
+func (s *Store) Get() interface{} { return s.value.Load() }
+
+// Watch watches all sources that implement SourceWatcher and reloads the
+// configuration each time one of them notifies a change. Each reload is made
+// on a new instance of the stored configuration type: defaults are set, all
+// sources are loaded and the result is validated (see Validate). Only if
+// every step succeed, the new configuration is atomically stored.
+// onChange, if not nil, is called after each reload with the new
+// configuration, or with the error that prevented the reload.
+// Watch blocks until ctx is done, or until a source fails to watch.
+func (c *Config) Watch(ctx context.Context, store *Store, onChange func(cfg interface{}, err error)) error {
+	var watchers []SourceWatcher
+	for _, source := range c.sources {
+		if w, ok := source.(SourceWatcher); ok {
+			watchers = append(watchers, w)
+		}
+	}
+
+	if len(watchers) == 0 {
+		return errors.New("no source implements the watch interface")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	var (
+		wg      sync.WaitGroup
+		errs    = make(chan error, len(watchers))
+		changes = make(chan struct{}, 1)
+		notify  = func() {
+			// notifications that happen before a reload are merged
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	)
+
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	for _, w := range watchers {
+		wg.Add(1)
+		go func(w SourceWatcher) {
+			defer wg.Done()
+			if err := w.Watch(ctx, notify); err != nil && ctx.Err() == nil {
+				errs <- fmt.Errorf("unable to watch source %s: %w", w.Name(), err)
+			}
+		}(w)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			return err
+		case <-changes:
+			cfg, err := c.reload(ctx, store.Get())
+			if err == nil {
+				store.value.Store(cfg)
+			}
+			if onChange != nil {
+				onChange(cfg, err)
+			}
+		}
+	}
+}
+
+func (c *Config) reload(ctx context.Context, current interface{}) (interface{}, error) {
+	var cfg = reflect.New(reflect.TypeOf(current).Elem()).Interface()
+
+	if err := c.LoadContext(ctx, cfg); err != nil {
+		return nil, fmt.Errorf("unable to reload configuration: %w", err)
+	}
+
+	if err := Validate(cfg); err != nil {
+		return nil, fmt.Errorf("reloaded configuration is invalid: %w", err)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSourceThatWatch struct {
+	m       sync.Mutex
+	values  stubSourceThatUseReflection
+	changes chan struct{}
+	err     error
+}
+
+func (s *stubSourceThatWatch) Name() string { return "stub watch" }
+
+func (s *stubSourceThatWatch) set(key, value string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.values[key] = value
+}
+
+func (s *stubSourceThatWatch) SetValueFromConfigTreePath(v *reflect.Value, name string) (bool, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.values.SetValueFromConfigTreePath(v, name)
+}
+
+func (s *stubSourceThatWatch) Watch(ctx context.Context, notify func()) error {
+	if s.err != nil {
+		return s.err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.changes:
+			notify()
+		}
+	}
+}
+
+type watchCfg struct {
+	Answer int
+}
+
+func (c watchCfg) Validate() error {
+	if c.Answer < 0 {
+		return errors.New("answer must be positive")
+	}
+	return nil
+}
+
+func Test_NewStore(t *testing.T) {
+	var cfg watchCfg
+
+	store, err := NewStore(&cfg)
+	require.NoError(t, err)
+	assert.Equal(t, &cfg, store.Get())
+
+	_, err = NewStore(nil)
+	require.Error(t, err)
+	_, err = NewStore(cfg)
+	require.Error(t, err)
+	_, err = NewStore((*watchCfg)(nil))
+	require.Error(t, err)
+}
+
+func TestConfig_Watch(t *testing.T) {
+	var (
+		src = &stubSourceThatWatch{
+			values:  stubSourceThatUseReflection{"answer": "42"},
+			changes: make(chan struct{}),
+		}
+		cfg     watchCfg
+		results = make(chan error)
+	)
+
+	c, err := New(WithRawSources(src))
+	require.NoError(t, err)
+	require.NoError(t, c.Load(&cfg))
+
+	store, err := NewStore(&cfg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- c.Watch(ctx, store, func(_ interface{}, err error) { results <- err })
+	}()
+
+	// a valid change is stored
+	src.set("answer", "1010")
+	src.changes <- struct{}{}
+	require.NoError(t, <-results)
+	assert.Equal(t, &watchCfg{Answer: 1010}, store.Get())
+	assert.Equal(t, 42, cfg.Answer)
+
+	// an invalid change is not
+	src.set("answer", "-1")
+	src.changes <- struct{}{}
+	require.Error(t, <-results)
+	assert.Equal(t, &watchCfg{Answer: 1010}, store.Get())
+
+	// a change that failed to load is not
+	src.set("answer", "nope")
+	src.changes <- struct{}{}
+	require.Error(t, <-results)
+	assert.Equal(t, &watchCfg{Answer: 1010}, store.Get())
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("watch did not stop when context is done")
+	}
+}
+
+func TestConfig_Watch_failures(t *testing.T) {
+	var cfg watchCfg
+
+	store, err := NewStore(&cfg)
+	require.NoError(t, err)
+
+	// no source can be watched
+	c, err := New(WithRawSources(stubSourceThatUseReflection{}))
+	require.NoError(t, err)
+	require.Error(t, c.Watch(context.Background(), store, nil))
+
+	// a source failed to watch
+	c, err = New(WithRawSources(&stubSourceThatWatch{err: errors.New("boum")}))
+	require.NoError(t, err)
+	require.Error(t, c.Watch(context.Background(), store, nil))
+}